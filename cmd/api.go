@@ -0,0 +1,110 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+var jsonHeader = http.Header{"content-type": []string{"application/json"}}
+
+// statusError is returned for unsuccessful API responses
+type statusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *statusError) Error() string {
+	return e.Message
+}
+
+// isStatus reports whether err is an API error with the given status code
+func isStatus(err error, code int) bool {
+	se, ok := err.(*statusError)
+	return ok && se.StatusCode == code
+}
+
+// doRequest sends a request to the API of the login, it is used for the
+// endpoints and query parameters which are not covered by the SDK
+func (l *Login) doRequest(method, path string, header http.Header, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, strings.TrimSuffix(l.URL, "/")+"/api/v1"+path, body)
+	if err != nil {
+		return nil, err
+	}
+	token, err := l.getToken()
+	if err != nil {
+		return nil, err
+	}
+	if len(token) != 0 {
+		req.Header.Set("Authorization", "token "+token)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	client, err := l.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+// getResponse sends a request and returns the body of a successful
+// response, errors are reported the same way the SDK does
+func (l *Login) getResponse(method, path string, header http.Header, body io.Reader) ([]byte, error) {
+	resp, err := l.doRequest(method, path, header, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return nil, responseError(resp.StatusCode, data)
+	}
+
+	return data, nil
+}
+
+// responseError builds the error of an unsuccessful response, the
+// messages are the same the SDK uses
+func responseError(code int, data []byte) *statusError {
+	switch code {
+	case 403:
+		return &statusError{code, "403 Forbidden"}
+	case 404:
+		return &statusError{code, "404 Not Found"}
+	case 409:
+		return &statusError{code, "409 Conflict"}
+	case 422:
+		return &statusError{code, fmt.Sprintf("422 Unprocessable Entity: %s", string(data))}
+	}
+
+	errMap := make(map[string]interface{})
+	if err := json.Unmarshal(data, &errMap); err == nil {
+		if msg, ok := errMap["message"].(string); ok {
+			return &statusError{code, msg}
+		}
+	}
+	return &statusError{code, fmt.Sprintf("Unknown API Error: %d %s", code, string(data))}
+}
+
+// getParsedResponse sends a request and decodes the JSON response into obj
+func (l *Login) getParsedResponse(method, path string, header http.Header, body io.Reader, obj interface{}) error {
+	data, err := l.getResponse(method, path, header, body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, obj)
+}
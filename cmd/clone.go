@@ -0,0 +1,92 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+
+	"code.gitea.io/sdk/gitea"
+	local_git "code.gitea.io/tea/modules/git"
+
+	"github.com/urfave/cli"
+)
+
+// the protocols tea clone can use
+const (
+	cloneHTTPS = "https"
+	cloneSSH   = "ssh"
+)
+
+// upstreamRemote is the remote added for the parent of a cloned fork
+const upstreamRemote = "upstream"
+
+// CmdClone represents to clone a repository
+var CmdClone = cli.Command{
+	Name:  "clone",
+	Usage: "Clone a repository",
+	Description: `Clone a repository of a login. The clone URL uses the protocol configured for
+the login with 'tea login edit --clone-protocol', https by default. When the
+repository is a fork its parent is added as the remote 'upstream'.`,
+	ArgsUsage: "<owner>/<repo> [<directory>]",
+	Action:    runClone,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "clone-protocol, p",
+			Usage: "Protocol to clone with, https or ssh (default of the login)",
+		},
+		LoginFlag,
+	},
+}
+
+func runClone(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return usageError("repository is required")
+	}
+	login, owner, repo, err := initRepoArgCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	protocol, err := getCloneProtocol(ctx)
+	if err != nil {
+		return err
+	}
+	if protocol == "" {
+		protocol = login.CloneProtocol
+	}
+
+	r, err := login.Client().GetRepo(owner, repo)
+	if err != nil {
+		return err
+	}
+
+	dir := ctx.Args().Get(1)
+	if dir == "" {
+		dir = r.Name
+	}
+	localRepo, err := local_git.Clone(cloneURL(r, protocol), dir)
+	if err != nil {
+		return err
+	}
+
+	if r.Fork && r.Parent != nil {
+		if err = localRepo.AddRemote(upstreamRemote, cloneURL(r.Parent, protocol)); err != nil {
+			return err
+		}
+		if _, err = localRepo.Run("fetch", "--", upstreamRemote); err != nil {
+			return err
+		}
+		fmt.Printf("Added remote %s for %s\n", upstreamRemote, r.Parent.FullName)
+	}
+	return nil
+}
+
+// cloneURL returns the URL to clone a repository with the protocol
+func cloneURL(r *gitea.Repository, protocol string) string {
+	if protocol == cloneSSH && r.SSHURL != "" {
+		return r.SSHURL
+	}
+	return r.CloneURL
+}
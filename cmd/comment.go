@@ -0,0 +1,211 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/utils"
+
+	"github.com/urfave/cli"
+)
+
+// CmdComment represents a command to comment on issues and pull requests
+var CmdComment = cli.Command{
+	Name:        "comment",
+	Usage:       "Add a comment to an issue or pull request",
+	Description: `Add a comment to an issue or pull request. The body is taken from --body, stdin or $EDITOR`,
+	ArgsUsage:   "<issue index> [<body>]",
+	Action:      runCommentAdd,
+	Subcommands: []cli.Command{
+		CmdCommentEdit,
+		CmdCommentDelete,
+	},
+	Flags: append([]cli.Flag{bodyFlag}, LoginRepoFlags...),
+}
+
+// CmdCommentEdit represents a sub command of comment to edit a comment
+var CmdCommentEdit = cli.Command{
+	Name:        "edit",
+	Usage:       "Edit a comment",
+	Description: `Edit a comment. The new body is taken from --body, stdin or $EDITOR`,
+	ArgsUsage:   "<issue index> <comment id>",
+	Action:      runCommentEdit,
+	Flags:       append([]cli.Flag{bodyFlag}, LoginRepoFlags...),
+}
+
+// CmdCommentDelete represents a sub command of comment to delete a comment
+var CmdCommentDelete = cli.Command{
+	Name:        "delete",
+	Usage:       "Delete a comment",
+	Description: `Delete a comment`,
+	ArgsUsage:   "<issue index> <comment id>",
+	Action:      runCommentDelete,
+	Flags:       LoginRepoFlags,
+}
+
+var bodyFlag = cli.StringFlag{
+	Name:  "body, b",
+	Usage: "comment body, use - to read it from stdin",
+}
+
+func runCommentAdd(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return usageError("issue index is required")
+	}
+	idx, err := argToIndex(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	body := strings.Join(ctx.Args().Tail(), " ")
+	if body == "" {
+		if body, err = readBody(ctx, ""); err != nil {
+			return err
+		}
+	}
+	if body == "" {
+		return errors.New("empty comment, aborting")
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	comment, err := login.Client().CreateIssueComment(owner, repo, idx, gitea.CreateIssueCommentOption{
+		Body: body,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(comment.HTMLURL)
+	return nil
+}
+
+func runCommentEdit(ctx *cli.Context) error {
+	idx, commentID, err := commentArgs(ctx)
+	if err != nil {
+		return err
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	var current string
+	if !ctx.IsSet("body") && utils.IsTerminal(os.Stdin) {
+		comment, err := getIssueComment(login, owner, repo, idx, commentID)
+		if err != nil {
+			return err
+		}
+		current = comment.Body
+	}
+
+	body, err := readBody(ctx, current)
+	if err != nil {
+		return err
+	}
+	if body == "" {
+		return errors.New("empty comment, aborting")
+	}
+
+	// the SDK builds a broken URL for editing comments, so call the API directly
+	bs, err := json.Marshal(gitea.EditIssueCommentOption{Body: body})
+	if err != nil {
+		return err
+	}
+	comment := new(gitea.Comment)
+	err = login.getParsedResponse("PATCH",
+		fmt.Sprintf("/repos/%s/%s/issues/comments/%d", owner, repo, commentID),
+		jsonHeader, bytes.NewReader(bs), comment)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(comment.HTMLURL)
+	return nil
+}
+
+func runCommentDelete(ctx *cli.Context) error {
+	idx, commentID, err := commentArgs(ctx)
+	if err != nil {
+		return err
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	return login.Client().DeleteIssueComment(owner, repo, idx, commentID)
+}
+
+func commentArgs(ctx *cli.Context) (int64, int64, error) {
+	if ctx.NArg() < 2 {
+		return 0, 0, usageError("issue index and comment id are required")
+	}
+	idx, err := argToIndex(ctx.Args().Get(0))
+	if err != nil {
+		return 0, 0, err
+	}
+	commentID, err := strconv.ParseInt(ctx.Args().Get(1), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return idx, commentID, nil
+}
+
+func getIssueComment(login *Login, owner, repo string, idx, commentID int64) (*gitea.Comment, error) {
+	comments, err := login.Client().ListIssueComments(owner, repo, idx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range comments {
+		if c.ID == commentID {
+			return c, nil
+		}
+	}
+	return nil, notFoundError("comment %d does not exist on #%d", commentID, idx)
+}
+
+// readBody returns the text given by the body flag, falls back to stdin
+// when it is not a terminal and to the editor otherwise
+func readBody(ctx *cli.Context, initial string) (string, error) {
+	body := ctx.String("body")
+	if body != "" && body != "-" {
+		return body, nil
+	}
+
+	if body == "-" || !utils.IsTerminal(os.Stdin) {
+		bs, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(bs)), nil
+	}
+
+	return utils.OpenEditor(initial, "")
+}
+
+func printComments(comments []*gitea.Comment) {
+	for _, c := range comments {
+		var name string
+		if c.Poster != nil {
+			name = c.Poster.UserName
+		}
+		fmt.Printf("\n--- #%d @%s commented %s ---\n%s\n", c.ID, name, formatTime(c.Created), c.Body)
+	}
+}
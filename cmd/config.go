@@ -5,52 +5,118 @@
 package cmd
 
 import (
-	"crypto/tls"
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"code.gitea.io/sdk/gitea"
 	local_git "code.gitea.io/tea/modules/git"
+	"code.gitea.io/tea/modules/httpclient"
 	"code.gitea.io/tea/modules/utils"
-	git_config "gopkg.in/src-d/go-git.v4/config"
 
 	"github.com/go-gitea/yaml"
 )
 
 // Login represents a login to a gitea server, you even could add multiple logins for one gitea server
 type Login struct {
-	Name     string `yaml:"name"`
-	URL      string `yaml:"url"`
-	Token    string `yaml:"token"`
-	Active   bool   `yaml:"active"`
-	SSHHost  string `yaml:"ssh_host"`
-	Insecure bool   `yaml:"insecure"`
+	Name  string `yaml:"name" json:"name"`
+	URL   string `yaml:"url" json:"url"`
+	Token string `yaml:"token,omitempty" json:"-"`
+	// TokenRef references the token inside a credential store, Token
+	// is only filled in at runtime then
+	TokenRef string `yaml:"token_ref,omitempty" json:"-"`
+	// TokenID is set for tokens created by tea, so they can be revoked
+	TokenID  int64  `yaml:"token_id,omitempty" json:"-"`
+	User     string `yaml:"user,omitempty" json:"user,omitempty"`
+	Active   bool   `yaml:"active" json:"active"`
+	SSHHost  string `yaml:"ssh_host" json:"ssh_host"`
+	SSHPort  int    `yaml:"ssh_port,omitempty" json:"ssh_port,omitempty"`
+	Insecure bool   `yaml:"insecure" json:"insecure"`
+	// CloneProtocol is the protocol tea clone uses, https or ssh
+	CloneProtocol string `yaml:"clone_protocol,omitempty" json:"clone_protocol,omitempty"`
+	// CAFile, ClientCert and ClientKey are PEM files for servers using a
+	// private certificate authority or TLS client authentication
+	CAFile     string `yaml:"ca_file,omitempty" json:"ca_file,omitempty"`
+	ClientCert string `yaml:"client_cert,omitempty" json:"client_cert,omitempty"`
+	ClientKey  string `yaml:"client_key,omitempty" json:"client_key,omitempty"`
+	// Proxy is the URL of a proxy server, "direct" ignores the environment
+	Proxy string `yaml:"proxy,omitempty" json:"proxy,omitempty"`
+	// ServerVersion is the Gitea version seen when the login was checked last
+	ServerVersion string `yaml:"server_version,omitempty" json:"server_version,omitempty"`
+
+	client *http.Client
 }
 
-// Client returns a client to operate Gitea API
+// Client returns a client to operate Gitea API, if the token or the
+// connection settings can not be loaded all requests fail with the error
 func (l *Login) Client() *gitea.Client {
-	client := gitea.NewClient(l.URL, l.Token)
-	if l.Insecure {
-		cookieJar, _ := cookiejar.New(nil)
-
-		client.SetHTTPClient(&http.Client{
-			Jar: cookieJar,
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-			},
-		})
+	httpClient, err := l.apiHTTPClient()
+	if err != nil {
+		httpClient = &http.Client{Transport: errorTransport{err}}
+	}
+	token, err := l.getToken()
+	if err != nil {
+		httpClient = &http.Client{Transport: errorTransport{err}}
 	}
+
+	client := gitea.NewClient(l.URL, token)
+	client.SetHTTPClient(httpClient)
 	return client
 }
 
+// apiHTTPClient returns the http client of the login for the SDK, which
+// reports unauthorized responses as errors
+func (l *Login) apiHTTPClient() (*http.Client, error) {
+	c, err := l.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client := *c
+	client.Transport = &authTransport{base}
+	return &client, nil
+}
+
+// checkLogin makes sure the token and the connection settings of the
+// login can be loaded, so failures are reported before any request
+func (l *Login) checkLogin() error {
+	if _, err := l.getToken(); err != nil {
+		return err
+	}
+	_, err := l.httpClient()
+	return err
+}
+
+// httpClient returns the http client used to talk to the Gitea server,
+// it is created once per login and run
+func (l *Login) httpClient() (*http.Client, error) {
+	if l.client != nil {
+		return l.client, nil
+	}
+
+	client, err := httpclient.New(httpclient.Options{
+		Insecure:   l.Insecure,
+		CAFile:     l.CAFile,
+		ClientCert: l.ClientCert,
+		ClientKey:  l.ClientKey,
+		Proxy:      l.Proxy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("login %s: %v", l.Name, err)
+	}
+	l.client = client
+	return client, nil
+}
+
 // GetSSHHost returns SSH host name
 func (l *Login) GetSSHHost() string {
 	if l.SSHHost != "" {
@@ -68,23 +134,41 @@ func (l *Login) GetSSHHost() string {
 // Config reprensents local configurations
 type Config struct {
 	Logins []Login `yaml:"logins"`
+	// CredentialStore is the backend new tokens are stored in
+	CredentialStore string `yaml:"credential_store,omitempty"`
+	// CredentialHelper is the git credential helper used by the helper backend
+	CredentialHelper string `yaml:"credential_helper,omitempty"`
+	// CreatedTokens are the access tokens created with this config, only
+	// they are revoked by 'tea login tokens --prune'
+	CreatedTokens []createdToken `yaml:"created_tokens,omitempty"`
+}
+
+// createdToken identifies an access token tea created on a server
+type createdToken struct {
+	URL  string `yaml:"url"`
+	User string `yaml:"user"`
+	ID   int64  `yaml:"id"`
 }
 
 var (
 	config         Config
 	yamlConfigPath string
+	// configDirErr is reported by loadConfig, as init can not return it
+	configDirErr error
 )
 
 func init() {
 	homeDir, err := utils.Home()
 	if err != nil {
-		log.Fatal("Retrieve home dir failed")
+		configDirErr = fmt.Errorf("retrieving the home directory failed: %v", err)
+		return
 	}
 
 	dir := filepath.Join(homeDir, ".tea")
 	err = os.MkdirAll(dir, os.ModePerm)
 	if err != nil {
-		log.Fatal("Init tea config dir", dir, "failed")
+		configDirErr = fmt.Errorf("creating the config directory %s failed: %v", dir, err)
+		return
 	}
 
 	yamlConfigPath = filepath.Join(dir, "tea.yml")
@@ -98,13 +182,21 @@ func splitRepo(repoPath string) (string, string) {
 	return repoPath, ""
 }
 
+// getActiveLogin returns the login named by TEA_LOGIN, the default
+// login or the first one if no default is set
 func getActiveLogin() (*Login, error) {
 	if len(config.Logins) == 0 {
-		return nil, errors.New("No available login")
+		return nil, &CommandError{ExitAuth, errors.New("No available login, add one with 'tea login add'")}
+	}
+	if name := os.Getenv("TEA_LOGIN"); name != "" {
+		if l := getLoginByName(name); l != nil {
+			return l, nil
+		}
+		return nil, notFoundError("login %s given by TEA_LOGIN does not exist", name)
 	}
-	for _, l := range config.Logins {
-		if l.Active {
-			return &l, nil
+	for i := range config.Logins {
+		if config.Logins[i].Active {
+			return &config.Logins[i], nil
 		}
 	}
 
@@ -112,30 +204,69 @@ func getActiveLogin() (*Login, error) {
 }
 
 func getLoginByName(name string) *Login {
-	for _, l := range config.Logins {
-		if l.Name == name {
-			return &l
+	for i := range config.Logins {
+		if config.Logins[i].Name == name {
+			return &config.Logins[i]
 		}
 	}
 	return nil
 }
 
-func addLogin(login Login) error {
-	for _, l := range config.Logins {
+// loginNameFromURL generates an unused login name from the host of a
+// server URL
+func loginNameFromURL(serverURL string) (string, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return "", err
+	}
+	name := u.Hostname()
+	if name == "" {
+		return "", fmt.Errorf("invalid server URL %s", serverURL)
+	}
+
+	unique := name
+	for i := 2; getLoginByName(unique) != nil; i++ {
+		unique = name + "-" + strconv.Itoa(i)
+	}
+	return unique, nil
+}
+
+// sameAccount reports whether two logins are for the same user on the same
+// server, tokens kept in a credential store are not loaded for comparing
+func sameAccount(a, b *Login) bool {
+	if !strings.EqualFold(strings.TrimSuffix(a.URL, "/"), strings.TrimSuffix(b.URL, "/")) {
+		return false
+	}
+	switch {
+	case a.User != "" && b.User != "":
+		return strings.EqualFold(a.User, b.User)
+	case a.TokenRef != "" && b.TokenRef != "":
+		return a.TokenRef == b.TokenRef
+	case a.Token != "" && b.Token != "":
+		return a.Token == b.Token
+	}
+	return false
+}
+
+// addLogin adds the login to the config and returns the stored login,
+// which is the existing one if it was added before
+func addLogin(login Login) (*Login, error) {
+	for i := range config.Logins {
+		l := &config.Logins[i]
 		if l.Name == login.Name {
-			if l.URL == login.URL && l.Token == login.Token {
-				return nil
+			if sameAccount(l, &login) {
+				return l, nil
 			}
-			return errors.New("login name has already been  used")
+			return nil, conflictError("login name has already been  used")
 		}
-		if l.URL == login.URL && l.Token == login.Token {
-			return errors.New("URL has been added")
+		if sameAccount(l, &login) {
+			return nil, conflictError("the account has already been added as login %s", l.Name)
 		}
 	}
 
 	u, err := url.Parse(login.URL)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if login.SSHHost == "" {
@@ -143,7 +274,7 @@ func addLogin(login Login) error {
 	}
 	config.Logins = append(config.Logins, login)
 
-	return nil
+	return &config.Logins[len(config.Logins)-1], nil
 }
 
 func isFileExist(fileName string) (bool, error) {
@@ -161,64 +292,174 @@ func isFileExist(fileName string) (bool, error) {
 }
 
 func loadConfig(ymlPath string) error {
+	if configDirErr != nil {
+		return configDirErr
+	}
 	exist, _ := isFileExist(ymlPath)
 	if exist {
 		Println("Found config file", ymlPath)
 		bs, err := ioutil.ReadFile(ymlPath)
 		if err != nil {
-			return err
+			return fmt.Errorf("loading the config file %s failed: %v", ymlPath, err)
 		}
 
 		err = yaml.Unmarshal(bs, &config)
 		if err != nil {
-			return err
+			return fmt.Errorf("loading the config file %s failed: %v", ymlPath, err)
 		}
+
+		return migrateTokens(ymlPath)
 	}
 
 	return nil
 }
 
 func saveConfig(ymlPath string) error {
-	bs, err := yaml.Marshal(&config)
+	c := config
+	c.Logins = make([]Login, len(config.Logins))
+	for i, l := range config.Logins {
+		// tokens loaded from a credential store must not end up in the file
+		if l.TokenRef != "" {
+			l.Token = ""
+		}
+		c.Logins[i] = l
+	}
+
+	bs, err := yaml.Marshal(&c)
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(ymlPath, bs, 0660)
+	if err = ioutil.WriteFile(ymlPath, bs, 0600); err != nil {
+		return err
+	}
+	// configs written by older versions were readable by the group
+	return os.Chmod(ymlPath, 0600)
 }
 
-func curGitRepoPath() (*Login, string, error) {
-	gitConfig := git_config.NewConfig()
-	bs, err := ioutil.ReadFile(filepath.Join(filepath.Dir(os.Args[0]), ".git", "config"))
+func curGitRepoPath(remote string, login *Login) (*Login, string, error) {
+	repo, err := local_git.CurrentRepo()
 	if err != nil {
 		return nil, "", err
 	}
-	if err := gitConfig.Unmarshal(bs); err != nil {
-		return nil, "", err
+
+	remotes := repo.RemoteNames()
+	if remote != "" {
+		remotes = []string{remote}
+	}
+	if len(remotes) == 0 {
+		return nil, "", errors.New("No remote found on this git repository")
 	}
-	remoteConfig, ok := gitConfig.Remotes["origin"]
-	if !ok || remoteConfig == nil {
-		return nil, "", errors.New("No remote origin found on this git repository")
+
+	var logins []*Login
+	if login != nil {
+		logins = []*Login{login}
+	} else {
+		for i := range config.Logins {
+			logins = append(logins, &config.Logins[i])
+		}
 	}
 
-	for _, l := range config.Logins {
-		for _, u := range remoteConfig.URLs {
-			p, err := local_git.ParseURL(strings.TrimSpace(u))
+	for _, name := range remotes {
+		urls, err := repo.RemoteURLs(name)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, u := range urls {
+			p, err := local_git.ParseURL(u)
 			if err != nil {
 				return nil, "", fmt.Errorf("Git remote URL parse failed: %s", err.Error())
 			}
-			if strings.EqualFold(p.Scheme, "http") || strings.EqualFold(p.Scheme, "https") {
-				if strings.HasPrefix(u, l.URL) {
-					ps := strings.Split(p.Path, "/")
-					path := strings.Join(ps[len(ps)-2:], "/")
-					return &l, strings.TrimSuffix(path, ".git"), nil
-				}
-			} else if strings.EqualFold(p.Scheme, "ssh") {
-				if l.GetSSHHost() == p.Host {
-					return &l, strings.TrimLeft(strings.TrimSuffix(p.Path, ".git"), "/"), nil
+
+			for _, l := range logins {
+				if repoPath, ok := l.matchRemote(p); ok {
+					return l, repoPath, nil
 				}
 			}
 		}
 	}
 
-	return nil, "", errors.New("No Gitea login found")
+	return nil, "", &CommandError{ExitAuth, errors.New("No Gitea login found")}
+}
+
+// matchRemote checks whether the remote URL u belongs to the login and
+// returns the owner/repo path of it
+func (l *Login) matchRemote(u *url.URL) (string, bool) {
+	var path string
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		base, err := url.Parse(l.URL)
+		if err != nil || !strings.EqualFold(hostWithPort(base), hostWithPort(u)) {
+			return "", false
+		}
+		prefix := strings.Trim(base.Path, "/")
+		path = strings.Trim(u.Path, "/")
+		if prefix != "" {
+			if !strings.HasPrefix(path, prefix+"/") {
+				return "", false
+			}
+			path = strings.TrimPrefix(path, prefix+"/")
+		}
+	case "ssh":
+		if !strings.EqualFold(l.GetSSHHost(), u.Hostname()) {
+			return "", false
+		}
+		// both ports default to 22 when not given
+		port, sshPort := u.Port(), l.SSHPort
+		if port == "" {
+			port = "22"
+		}
+		if sshPort == 0 {
+			sshPort = 22
+		}
+		if port != strconv.Itoa(sshPort) {
+			return "", false
+		}
+		path = strings.Trim(u.Path, "/")
+	default:
+		return "", false
+	}
+
+	ps := strings.Split(strings.TrimSuffix(path, ".git"), "/")
+	if len(ps) < 2 {
+		return "", false
+	}
+	return strings.Join(ps[len(ps)-2:], "/"), true
+}
+
+// hostWithPort returns host:port of u, filling in the default port of
+// the scheme so that explicit and implicit default ports compare equal
+func hostWithPort(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return u.Hostname() + ":443"
+	case "http":
+		return u.Hostname() + ":80"
+	}
+	return u.Host
+}
+
+// findRemote returns the name of the remote of the local repository
+// pointing to repoPath on the login, the second value reports whether
+// the remote is accessed via SSH
+func findRemote(repo *local_git.Repo, login *Login, repoPath string) (string, bool) {
+	for _, name := range repo.RemoteNames() {
+		urls, err := repo.RemoteURLs(name)
+		if err != nil {
+			continue
+		}
+		for _, u := range urls {
+			p, err := local_git.ParseURL(u)
+			if err != nil {
+				continue
+			}
+			if path, ok := login.matchRemote(p); ok && strings.EqualFold(path, repoPath) {
+				return name, strings.EqualFold(p.Scheme, "ssh")
+			}
+		}
+	}
+	return "", false
 }
@@ -0,0 +1,230 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	local_git "code.gitea.io/tea/modules/git"
+
+	git_config "gopkg.in/src-d/go-git.v4/config"
+)
+
+func TestMatchRemote(t *testing.T) {
+	tests := []struct {
+		name   string
+		login  Login
+		remote string
+		path   string
+		match  bool
+	}{
+		{"https", Login{URL: "https://gitea.com"}, "https://gitea.com/owner/repo.git", "owner/repo", true},
+		{"https without .git", Login{URL: "https://gitea.com/"}, "https://gitea.com/owner/repo", "owner/repo", true},
+		{"host case", Login{URL: "https://Gitea.com"}, "https://gitea.COM/owner/repo.git", "owner/repo", true},
+		{"other host", Login{URL: "https://gitea.com"}, "https://gitea.io/owner/repo.git", "", false},
+		{"explicit default port", Login{URL: "https://gitea.com:443"}, "https://gitea.com/owner/repo.git", "owner/repo", true},
+		{"implicit default port", Login{URL: "http://gitea.com"}, "http://gitea.com:80/owner/repo.git", "owner/repo", true},
+		{"other port", Login{URL: "http://gitea.com:3000"}, "http://gitea.com/owner/repo.git", "", false},
+		{"sub path", Login{URL: "https://example.com/gitea"}, "https://example.com/gitea/owner/repo.git", "owner/repo", true},
+		{"outside the sub path", Login{URL: "https://example.com/gitea"}, "https://example.com/other/owner/repo.git", "", false},
+		{"missing repo", Login{URL: "https://gitea.com"}, "https://gitea.com/owner", "", false},
+		{"scp-like ssh", Login{URL: "https://gitea.com"}, "git@gitea.com:owner/repo.git", "owner/repo", true},
+		{"ssh host", Login{URL: "https://gitea.com", SSHHost: "ssh.gitea.com"}, "ssh://git@ssh.gitea.com/owner/repo.git", "owner/repo", true},
+		{"ssh other host", Login{URL: "https://gitea.com", SSHHost: "ssh.gitea.com"}, "git@gitea.com:owner/repo.git", "", false},
+		{"ssh port 22 given", Login{URL: "https://gitea.com"}, "ssh://git@gitea.com:22/owner/repo.git", "owner/repo", true},
+		{"ssh login port 22", Login{URL: "https://gitea.com", SSHPort: 22}, "git@gitea.com:owner/repo.git", "owner/repo", true},
+		{"ssh port", Login{URL: "https://gitea.com", SSHPort: 2222}, "ssh://git@gitea.com:2222/owner/repo.git", "owner/repo", true},
+		{"ssh other port", Login{URL: "https://gitea.com", SSHPort: 2222}, "ssh://git@gitea.com:2223/owner/repo.git", "", false},
+		{"ssh port missing in remote", Login{URL: "https://gitea.com", SSHPort: 2222}, "git@gitea.com:owner/repo.git", "", false},
+		{"ssh port missing in login", Login{URL: "https://gitea.com"}, "ssh://git@gitea.com:2222/owner/repo.git", "", false},
+		{"unsupported scheme", Login{URL: "https://gitea.com"}, "git://gitea.com/owner/repo.git", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := local_git.ParseURL(tt.remote)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path, ok := tt.login.matchRemote(u)
+			if ok != tt.match || path != tt.path {
+				t.Errorf("matchRemote(%s) = %q, %v, expected %q, %v", tt.remote, path, ok, tt.path, tt.match)
+			}
+		})
+	}
+}
+
+func TestCurGitRepoPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tea-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	gitDir := filepath.Join(dir, ".git")
+	for _, d := range []string{"objects", "refs"} {
+		if err = os.MkdirAll(filepath.Join(gitDir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"HEAD": "ref: refs/heads/master\n",
+		"config": `[remote "origin"]
+	url = https://gitea.io/owner/repo.git
+[remote "upstream"]
+	url = git@gitea.com:org/repo.git
+`,
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(gitDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	// GIT_DIR would take precedence over the working directory
+	if gitDirEnv, ok := os.LookupEnv("GIT_DIR"); ok {
+		defer os.Setenv("GIT_DIR", gitDirEnv)
+		os.Unsetenv("GIT_DIR")
+	}
+
+	saved := config.Logins
+	defer func() { config.Logins = saved }()
+	config.Logins = []Login{
+		{Name: "other", URL: "https://example.com"},
+		{Name: "gitea", URL: "https://gitea.com"},
+		{Name: "gitea.io", URL: "https://gitea.io"},
+	}
+
+	tests := []struct {
+		remote string
+		login  int
+		path   string
+	}{
+		{"", 2, "owner/repo"},
+		{"origin", 2, "owner/repo"},
+		{"upstream", 1, "org/repo"},
+	}
+	for _, tt := range tests {
+		login, path, err := curGitRepoPath(tt.remote, nil)
+		if err != nil {
+			t.Errorf("curGitRepoPath(%q) failed: %v", tt.remote, err)
+			continue
+		}
+		// the login must point into the config, not to a copy of it
+		if login != &config.Logins[tt.login] || path != tt.path {
+			t.Errorf("curGitRepoPath(%q) = %s, %s, expected %s, %s", tt.remote,
+				login.Name, path, config.Logins[tt.login].Name, tt.path)
+		}
+	}
+
+	if _, _, err = curGitRepoPath("", &config.Logins[0]); err == nil {
+		t.Error("expected an error for a login without a matching remote")
+	}
+}
+
+func TestFindRemote(t *testing.T) {
+	cfg := git_config.NewConfig()
+	cfg.Remotes = map[string]*git_config.RemoteConfig{
+		"origin":   {Name: "origin", URLs: []string{"git@gitea.com:user/repo.git"}},
+		"upstream": {Name: "upstream", URLs: []string{"https://gitea.com/org/repo.git"}},
+		"mirror":   {Name: "mirror", URLs: []string{"https://example.com/org/repo.git"}},
+	}
+	repo := &local_git.Repo{Config: cfg}
+	login := &Login{URL: "https://gitea.com"}
+
+	tests := []struct {
+		repoPath string
+		remote   string
+		ssh      bool
+	}{
+		{"user/repo", "origin", true},
+		{"User/Repo", "origin", true},
+		{"org/repo", "upstream", false},
+		{"other/repo", "", false},
+	}
+	for _, tt := range tests {
+		remote, ssh := findRemote(repo, login, tt.repoPath)
+		if remote != tt.remote || ssh != tt.ssh {
+			t.Errorf("findRemote(%s) = %q, %v, expected %q, %v", tt.repoPath, remote, ssh, tt.remote, tt.ssh)
+		}
+	}
+}
+
+func TestSameAccount(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Login
+		same bool
+	}{
+		{"same user", Login{URL: "https://gitea.com", User: "alice"}, Login{URL: "https://gitea.com/", User: "Alice"}, true},
+		{"other user", Login{URL: "https://gitea.com", User: "alice"}, Login{URL: "https://gitea.com", User: "bob"}, false},
+		{"other server", Login{URL: "https://gitea.com", User: "alice"}, Login{URL: "https://gitea.io", User: "alice"}, false},
+		// tokens in a credential store are not loaded into the config
+		{"same token ref", Login{URL: "https://gitea.com", TokenRef: "ref-1"}, Login{URL: "https://gitea.com", TokenRef: "ref-1"}, true},
+		{"other token ref", Login{URL: "https://gitea.com", TokenRef: "ref-1"}, Login{URL: "https://gitea.com", TokenRef: "ref-2"}, false},
+		{"same token", Login{URL: "https://gitea.com", Token: "abc"}, Login{URL: "https://gitea.com", Token: "abc"}, true},
+		{"no tokens", Login{URL: "https://gitea.com", TokenRef: "ref-1"}, Login{URL: "https://gitea.com", Token: ""}, false},
+		{"user wins over token", Login{URL: "https://gitea.com", User: "alice", Token: "abc"}, Login{URL: "https://gitea.com", User: "bob", Token: "abc"}, false},
+	}
+
+	for _, tt := range tests {
+		if same := sameAccount(&tt.a, &tt.b); same != tt.same {
+			t.Errorf("%s: sameAccount() = %v, expected %v", tt.name, same, tt.same)
+		}
+	}
+}
+
+func TestAddLogin(t *testing.T) {
+	saved := config.Logins
+	defer func() { config.Logins = saved }()
+	config.Logins = []Login{
+		{Name: "gitea", URL: "https://gitea.com", User: "alice", TokenRef: "ref-1"},
+	}
+
+	tests := []struct {
+		name     string
+		login    Login
+		conflict bool
+		index    int
+	}{
+		{"existing login", Login{Name: "gitea", URL: "https://gitea.com", User: "alice"}, false, 0},
+		{"name taken", Login{Name: "gitea", URL: "https://gitea.com", User: "bob"}, true, 0},
+		{"account added", Login{Name: "other", URL: "https://gitea.com", TokenRef: "ref-1"}, true, 0},
+		{"new login", Login{Name: "io", URL: "https://gitea.io:3000", User: "alice"}, false, 1},
+	}
+	for _, tt := range tests {
+		stored, err := addLogin(tt.login)
+		if tt.conflict {
+			if ce, ok := err.(*CommandError); !ok || ce.Code != ExitConflict {
+				t.Errorf("%s: expected a conflict, got %v", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: addLogin() failed: %v", tt.name, err)
+			continue
+		}
+		if stored != &config.Logins[tt.index] {
+			t.Errorf("%s: addLogin() did not return the stored login", tt.name)
+		}
+	}
+
+	if len(config.Logins) != 2 {
+		t.Fatalf("expected 2 logins, got %d", len(config.Logins))
+	}
+	if host := config.Logins[1].SSHHost; host != "gitea.io" {
+		t.Errorf("expected the SSH host gitea.io, got %s", host)
+	}
+}
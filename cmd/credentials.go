@@ -0,0 +1,189 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/tea/modules/credentials"
+	"code.gitea.io/tea/modules/utils"
+)
+
+// credentialStores caches the opened stores, so that a passphrase is
+// asked at most once per run
+var credentialStores = map[string]credentials.Store{}
+
+// openCredentialStore returns the store of a backend, nil for plain
+func openCredentialStore(backend string) (credentials.Store, error) {
+	if store, ok := credentialStores[backend]; ok {
+		return store, nil
+	}
+
+	var store credentials.Store
+	switch backend {
+	case "", credentials.BackendPlain:
+		return nil, nil
+	case credentials.BackendFile:
+		path := filepath.Join(filepath.Dir(yamlConfigPath), "credentials.enc")
+		store = credentials.NewFileStore(path, credentialPassphrase)
+	case credentials.BackendHelper:
+		store = credentials.NewHelperStore(config.CredentialHelper)
+	default:
+		return nil, usageError("unknown credential store %s", backend)
+	}
+
+	credentialStores[backend] = store
+	return store, nil
+}
+
+// credentialPassphrase reads the passphrase of the encrypted credential
+// file from TEA_CREDENTIALS_PASSPHRASE or asks for it
+func credentialPassphrase(confirm bool) (string, error) {
+	if pass := os.Getenv("TEA_CREDENTIALS_PASSPHRASE"); pass != "" {
+		return pass, nil
+	}
+	if !utils.IsTerminal(os.Stdin) {
+		return "", errors.New("the credential file is encrypted, set TEA_CREDENTIALS_PASSPHRASE")
+	}
+
+	pass, err := utils.PromptPassword("Passphrase for the tea credentials: ")
+	if err != nil {
+		return "", err
+	}
+	if pass == "" {
+		return "", errors.New("empty passphrase")
+	}
+	if confirm {
+		again, err := utils.PromptPassword("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != pass {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return pass, nil
+}
+
+// splitTokenRef splits a token reference into backend and key id
+func splitTokenRef(ref string) (string, string) {
+	p := strings.SplitN(ref, ":", 2)
+	if len(p) < 2 {
+		return p[0], ""
+	}
+	return p[0], p[1]
+}
+
+// getToken returns the token of the login, loading it from the
+// credential store when needed
+func (l *Login) getToken() (string, error) {
+	if l.Token != "" || l.TokenRef == "" {
+		return l.Token, nil
+	}
+
+	backend, id := splitTokenRef(l.TokenRef)
+	store, err := openCredentialStore(backend)
+	if err != nil {
+		return "", err
+	}
+	if store == nil {
+		return "", fmt.Errorf("invalid token reference of login %s", l.Name)
+	}
+
+	token, err := store.Get(credentials.Key{ID: id, URL: l.URL})
+	if err != nil {
+		return "", fmt.Errorf("loading the token of login %s failed: %v", l.Name, err)
+	}
+	l.Token = token
+	return token, nil
+}
+
+// storeToken moves the token of the login into the configured credential
+// store, for the plain backend it is kept inline
+func (l *Login) storeToken() error {
+	token, err := l.getToken()
+	if err != nil {
+		return err
+	}
+
+	backend := config.CredentialStore
+	if backend == "" {
+		backend = credentials.BackendPlain
+	}
+	if current, _ := splitTokenRef(l.TokenRef); current == backend ||
+		(l.TokenRef == "" && backend == credentials.BackendPlain) {
+		return nil
+	}
+
+	store, err := openCredentialStore(backend)
+	if err != nil {
+		return err
+	}
+
+	oldRef := l.TokenRef
+	if store == nil {
+		l.TokenRef = ""
+	} else {
+		id := l.Name
+		if oldRef != "" {
+			_, id = splitTokenRef(oldRef)
+		}
+		if err = store.Set(credentials.Key{ID: id, URL: l.URL}, token); err != nil {
+			return err
+		}
+		l.TokenRef = backend + ":" + id
+	}
+	l.Token = token
+
+	if oldRef != "" {
+		old := *l
+		old.TokenRef = oldRef
+		return old.deleteToken()
+	}
+	return nil
+}
+
+// deleteToken removes the token of the login from its credential store
+func (l *Login) deleteToken() error {
+	if l.TokenRef == "" {
+		return nil
+	}
+
+	backend, id := splitTokenRef(l.TokenRef)
+	store, err := openCredentialStore(backend)
+	if err != nil || store == nil {
+		return err
+	}
+	return store.Delete(credentials.Key{ID: id, URL: l.URL})
+}
+
+// migrateTokens moves plaintext tokens into the configured credential
+// store, so existing configs are converted the first time they are used
+func migrateTokens(ymlPath string) error {
+	if config.CredentialStore == "" || config.CredentialStore == credentials.BackendPlain {
+		return nil
+	}
+
+	var migrated bool
+	for i := range config.Logins {
+		if config.Logins[i].TokenRef != "" || config.Logins[i].Token == "" {
+			continue
+		}
+		if err := config.Logins[i].storeToken(); err != nil {
+			return fmt.Errorf("moving the token of login %s into the credential store failed: %v",
+				config.Logins[i].Name, err)
+		}
+		migrated = true
+	}
+
+	if !migrated {
+		return nil
+	}
+	return saveConfig(ymlPath)
+}
@@ -0,0 +1,400 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/output"
+
+	"github.com/go-gitea/yaml"
+	"github.com/urfave/cli"
+)
+
+// CmdDashboard represents to list the work of the login's user
+var CmdDashboard = cli.Command{
+	Name:  "dashboard",
+	Usage: "Show the issues and pull requests of the login's user",
+	Description: `List the issues and pull requests assigned to or created by the login's user on
+all repositories, grouped by repository with the recently updated ones first.
+Entries which are new or changed since the last run are marked with a *.`,
+	Action: runDashboard,
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "all-logins, a",
+			Usage: "Include the issues of all logins",
+		},
+		cli.StringFlag{
+			Name:  "state, s",
+			Value: "open",
+			Usage: "Filter by state: open, closed or all",
+		},
+		cli.StringFlag{
+			Name:  "role",
+			Usage: "Only list the issues the user is assigned to or created: assigned or created",
+		},
+		cli.BoolFlag{
+			Name:  "keep-unread",
+			Usage: "Do not mark the listed entries as read",
+		},
+		LoginFlag,
+	}, OutputFlags...),
+}
+
+// dashboardItem is an issue or pull request listed on the dashboard
+type dashboardItem struct {
+	Login      string    `json:"login"`
+	Repository string    `json:"repository"`
+	Index      int64     `json:"index"`
+	Kind       string    `json:"kind"`
+	Title      string    `json:"title"`
+	State      string    `json:"state"`
+	Roles      []string  `json:"roles"`
+	Updated    time.Time `json:"updated"`
+	Unread     bool      `json:"unread"`
+}
+
+// key identifies the item in the dashboard state
+func (i *dashboardItem) key() string {
+	return fmt.Sprintf("%s/%s#%d", i.Login, i.Repository, i.Index)
+}
+
+// dashboardState maps the keys of the listed open items to their last
+// update seen
+type dashboardState map[string]dashboardSeen
+
+// dashboardSeen is the last update of an item seen and the roles it was
+// listed in
+type dashboardSeen struct {
+	Updated int64    `yaml:"updated"`
+	Roles   []string `yaml:"roles,flow"`
+}
+
+func dashboardStatePath() string {
+	return filepath.Join(filepath.Dir(yamlConfigPath), "dashboard.yml")
+}
+
+func loadDashboardState() (dashboardState, error) {
+	state := make(dashboardState)
+	bs, err := ioutil.ReadFile(dashboardStatePath())
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(bs, &state); err != nil {
+		return nil, fmt.Errorf("loading the dashboard state %s failed: %v", dashboardStatePath(), err)
+	}
+	return state, nil
+}
+
+func saveDashboardState(state dashboardState) error {
+	bs, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dashboardStatePath(), bs, 0600)
+}
+
+func runDashboard(ctx *cli.Context) error {
+	state := strings.ToLower(ctx.String("state"))
+	switch state {
+	case string(gitea.StateOpen), string(gitea.StateClosed), "all":
+	default:
+		return usageError("unknown state %s, expected open, closed or all", state)
+	}
+	roles := []string{"assigned", "created"}
+	switch ctx.String("role") {
+	case "":
+	case "assigned", "created":
+		roles = []string{ctx.String("role")}
+	default:
+		return usageError("unknown role %s, expected assigned or created", ctx.String("role"))
+	}
+
+	var logins []*Login
+	if ctx.Bool("all-logins") {
+		if err := loadConfig(yamlConfigPath); err != nil {
+			return err
+		}
+		for i := range config.Logins {
+			logins = append(logins, &config.Logins[i])
+		}
+		if len(logins) == 0 {
+			return &CommandError{ExitAuth, errors.New("No available login, add one with 'tea login add'")}
+		}
+	} else {
+		login, err := initLoginCommand(ctx)
+		if err != nil {
+			return err
+		}
+		logins = []*Login{login}
+	}
+
+	var items []*dashboardItem
+	var failed int
+	listed := make(map[string]bool)
+	for _, login := range logins {
+		var loginItems []*dashboardItem
+		err := login.checkLogin()
+		if err == nil {
+			loginItems, err = listDashboardItems(login, state, roles)
+		}
+		if err != nil {
+			// one unreachable server should not hide the others
+			if len(logins) == 1 {
+				return err
+			}
+			failed++
+			fmt.Fprintf(os.Stderr, "Skipping login %s: %v\n", login.Name, err)
+			continue
+		}
+		items = append(items, loginItems...)
+		listed[login.Name] = true
+	}
+	if failed == len(logins) {
+		return fmt.Errorf("all %d logins failed", failed)
+	}
+
+	seen, err := loadDashboardState()
+	if err != nil {
+		return err
+	}
+	var unread int
+	for _, item := range items {
+		if item.Updated.Unix() > seen[item.key()].Updated {
+			item.Unread = true
+			unread++
+		}
+	}
+	sortDashboardItems(items)
+
+	if len(items) == 0 && !isStructuredOutput(ctx) {
+		fmt.Println("Nothing to do")
+	} else {
+		if err = printTable(ctx, dashboardTable(items, len(logins) > 1)); err != nil {
+			return err
+		}
+		if !isStructuredOutput(ctx) && unread > 0 {
+			fmt.Printf("\n%d new or changed since the last run\n", unread)
+		}
+	}
+
+	if ctx.Bool("keep-unread") {
+		return nil
+	}
+	return saveDashboardState(nextDashboardState(seen, items, listed, state, roles))
+}
+
+// nextDashboardState marks the items as seen. Only open items are kept, so
+// closed and vanished items do not pile up, but the ones outside of the
+// state and roles listed are left alone.
+func nextDashboardState(seen dashboardState, items []*dashboardItem, listed map[string]bool, state string, roles []string) dashboardState {
+	next := make(dashboardState)
+	for key, s := range seen {
+		if !listed[strings.SplitN(key, "/", 2)[0]] || !couldListDashboardItem(s, state, roles) {
+			next[key] = s
+		}
+	}
+	for _, item := range items {
+		key := item.key()
+		if item.State != string(gitea.StateOpen) {
+			delete(next, key)
+			continue
+		}
+		// the roles not listed this time may still apply
+		itemRoles := append([]string{}, item.Roles...)
+		for _, role := range seen[key].Roles {
+			if !containsString(roles, role) && !containsString(itemRoles, role) {
+				itemRoles = append(itemRoles, role)
+			}
+		}
+		sort.Strings(itemRoles)
+		next[key] = dashboardSeen{Updated: item.Updated.Unix(), Roles: itemRoles}
+	}
+	return next
+}
+
+// couldListDashboardItem reports whether a run listing the state and roles
+// would have listed the open item seen before
+func couldListDashboardItem(s dashboardSeen, state string, roles []string) bool {
+	if state == string(gitea.StateClosed) {
+		return false
+	}
+	for _, role := range s.Roles {
+		if containsString(roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// listDashboardItems lists the issues and pull requests of the login's
+// user in the given roles
+func listDashboardItems(login *Login, state string, roles []string) ([]*dashboardItem, error) {
+	me := login.User
+	if me == "" {
+		u, err := login.Client().GetMyUserInfo()
+		if err != nil {
+			return nil, err
+		}
+		me = u.UserName
+	}
+
+	var items []*dashboardItem
+	byID := make(map[int64]*dashboardItem)
+	for _, role := range roles {
+		issues, err := searchIssues(login, state, role)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			// older servers ignore the role filters
+			if !hasDashboardRole(issue, role, me) {
+				continue
+			}
+			if item, ok := byID[issue.ID]; ok {
+				item.Roles = append(item.Roles, role)
+				continue
+			}
+			item := &dashboardItem{
+				Login:   login.Name,
+				Index:   issue.Index,
+				Kind:    "issue",
+				Title:   issue.Title,
+				State:   string(issue.State),
+				Roles:   []string{role},
+				Updated: issue.Updated,
+			}
+			if issue.Repository != nil {
+				item.Repository = issue.Repository.FullName
+			} else {
+				item.Repository = issueRepoFromURL(issue.URL)
+			}
+			if issue.PullRequest != nil {
+				item.Kind = "pull"
+			}
+			byID[issue.ID] = item
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// issueRepoFromURL returns the <owner>/<repo> of an issue API URL, for
+// servers which do not name the repository of an issue
+func issueRepoFromURL(u string) string {
+	p := strings.Split(u, "/repos/")
+	if len(p) < 2 {
+		return ""
+	}
+	owner, repo := splitRepo(p[len(p)-1])
+	if owner == "" || repo == "" {
+		return ""
+	}
+	return owner + "/" + repo
+}
+
+func hasDashboardRole(issue *repoIssue, role, me string) bool {
+	if role == "created" {
+		return issue.Poster != nil && strings.EqualFold(issue.Poster.UserName, me)
+	}
+	for _, u := range append(issue.Assignees, issue.Assignee) {
+		if u != nil && strings.EqualFold(u.UserName, me) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchIssues lists the issues and pull requests across all repositories
+// the login's user is assigned to or created, the SDK has no search
+func searchIssues(login *Login, state, role string) ([]*repoIssue, error) {
+	var result []*repoIssue
+	for page := 1; ; page++ {
+		q := url.Values{}
+		q.Set("state", state)
+		q.Set(role, "true")
+		q.Set("page", strconv.Itoa(page))
+
+		issues := make([]*repoIssue, 0, 10)
+		err := login.getParsedResponse("GET", "/repos/issues/search?"+q.Encode(), nil, nil, &issues)
+		if err != nil {
+			if statusCode(err) == http.StatusNotFound {
+				return nil, fmt.Errorf("the server of login %s can not search issues, Gitea 1.10 or newer is required", login.Name)
+			}
+			return nil, err
+		}
+		if len(issues) == 0 {
+			return result, nil
+		}
+		result = append(result, issues...)
+	}
+}
+
+// sortDashboardItems groups the items by repository, the repositories and
+// the items within them are ordered by the latest update
+func sortDashboardItems(items []*dashboardItem) {
+	latest := make(map[string]time.Time)
+	for _, item := range items {
+		group := item.Login + "/" + item.Repository
+		if item.Updated.After(latest[group]) {
+			latest[group] = item.Updated
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		gi, gj := items[i].Login+"/"+items[i].Repository, items[j].Login+"/"+items[j].Repository
+		if gi != gj {
+			if !latest[gi].Equal(latest[gj]) {
+				return latest[gi].After(latest[gj])
+			}
+			return gi < gj
+		}
+		return items[i].Updated.After(items[j].Updated)
+	})
+}
+
+func dashboardTable(items []*dashboardItem, withLogin bool) *output.Table {
+	t := &output.Table{
+		Columns: []string{"", "repository", "index", "kind", "title", "state", "role", "updated"},
+		Items:   items,
+	}
+	if withLogin {
+		t.Columns = append([]string{"", "login"}, t.Columns[1:]...)
+	}
+	for _, item := range items {
+		marker := ""
+		if item.Unread {
+			marker = "*"
+		}
+		row := []string{marker, item.Repository, "#" + strconv.FormatInt(item.Index, 10), item.Kind,
+			item.Title, item.State, strings.Join(item.Roles, ", "), formatTime(item.Updated)}
+		if withLogin {
+			row = append([]string{marker, item.Login}, row[1:]...)
+		}
+		t.AddRow(row...)
+	}
+	return t
+}
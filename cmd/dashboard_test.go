@@ -0,0 +1,88 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNextDashboardState(t *testing.T) {
+	updated := time.Unix(2000, 0)
+	seen := dashboardState{
+		"gitea/owner/repo#1": {Updated: 1000, Roles: []string{"assigned"}},
+		"gitea/owner/repo#2": {Updated: 1000, Roles: []string{"created"}},
+		"gitea/owner/repo#3": {Updated: 1000, Roles: []string{"assigned", "created"}},
+		"other/owner/repo#1": {Updated: 1000, Roles: []string{"assigned"}},
+	}
+	item := func(index int64, state string, roles ...string) *dashboardItem {
+		return &dashboardItem{Login: "gitea", Repository: "owner/repo", Index: index,
+			State: state, Roles: roles, Updated: updated}
+	}
+	listed := map[string]bool{"gitea": true}
+
+	tests := []struct {
+		name     string
+		state    string
+		roles    []string
+		items    []*dashboardItem
+		expected dashboardState
+	}{
+		{
+			name:  "all roles",
+			state: "open",
+			roles: []string{"assigned", "created"},
+			items: []*dashboardItem{item(1, "open", "assigned"), item(4, "open", "created")},
+			expected: dashboardState{
+				"gitea/owner/repo#1": {Updated: 2000, Roles: []string{"assigned"}},
+				"gitea/owner/repo#4": {Updated: 2000, Roles: []string{"created"}},
+				"other/owner/repo#1": {Updated: 1000, Roles: []string{"assigned"}},
+			},
+		},
+		{
+			// #2 was only created, so it could not have been listed
+			name:  "assigned only",
+			state: "open",
+			roles: []string{"assigned"},
+			items: []*dashboardItem{item(3, "open", "assigned")},
+			expected: dashboardState{
+				"gitea/owner/repo#2": {Updated: 1000, Roles: []string{"created"}},
+				"gitea/owner/repo#3": {Updated: 2000, Roles: []string{"assigned", "created"}},
+				"other/owner/repo#1": {Updated: 1000, Roles: []string{"assigned"}},
+			},
+		},
+		{
+			// closed runs can not tell about the open items
+			name:  "closed only",
+			state: "closed",
+			roles: []string{"assigned", "created"},
+			items: []*dashboardItem{item(2, "closed", "created")},
+			expected: dashboardState{
+				"gitea/owner/repo#1": {Updated: 1000, Roles: []string{"assigned"}},
+				"gitea/owner/repo#3": {Updated: 1000, Roles: []string{"assigned", "created"}},
+				"other/owner/repo#1": {Updated: 1000, Roles: []string{"assigned"}},
+			},
+		},
+		{
+			name:  "all states",
+			state: "all",
+			roles: []string{"created"},
+			items: []*dashboardItem{item(2, "closed", "created"), item(3, "open", "created")},
+			expected: dashboardState{
+				"gitea/owner/repo#1": {Updated: 1000, Roles: []string{"assigned"}},
+				"gitea/owner/repo#3": {Updated: 2000, Roles: []string{"assigned", "created"}},
+				"other/owner/repo#1": {Updated: 1000, Roles: []string{"assigned"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		actual := nextDashboardState(seen, tt.items, listed, tt.state, tt.roles)
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("%s: nextDashboardState() = %v, expected %v", tt.name, actual, tt.expected)
+		}
+	}
+}
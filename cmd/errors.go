@@ -0,0 +1,174 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"code.gitea.io/tea/modules/output"
+
+	"github.com/urfave/cli"
+)
+
+// Exit codes of tea, scripts can use them to tell the kinds of failures
+// apart
+const (
+	ExitError    = 1
+	ExitUsage    = 2
+	ExitAuth     = 3
+	ExitNotFound = 4
+	ExitConflict = 5
+	ExitNetwork  = 6
+)
+
+// CommandError is a failure of a command together with its exit code
+type CommandError struct {
+	Code int
+	Err  error
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+func usageError(format string, a ...interface{}) error {
+	return &CommandError{ExitUsage, fmt.Errorf(format, a...)}
+}
+
+func notFoundError(format string, a ...interface{}) error {
+	return &CommandError{ExitNotFound, fmt.Errorf(format, a...)}
+}
+
+func conflictError(format string, a ...interface{}) error {
+	return &CommandError{ExitConflict, fmt.Errorf(format, a...)}
+}
+
+// sdkStatusErrors are the errors the SDK returns for unsuccessful
+// responses, it does not keep the status code
+var sdkStatusErrors = map[string]int{
+	"403 Forbidden": http.StatusForbidden,
+	"404 Not Found": http.StatusNotFound,
+	"409 Conflict":  http.StatusConflict,
+}
+
+// statusCode returns the HTTP status code of an API error, or 0 if err
+// is no API error
+func statusCode(err error) int {
+	switch e := err.(type) {
+	case nil:
+		return 0
+	case *statusError:
+		return e.StatusCode
+	case *url.Error:
+		return statusCode(e.Err)
+	}
+	if code, ok := sdkStatusErrors[err.Error()]; ok {
+		return code
+	}
+	if strings.HasPrefix(err.Error(), "422 Unprocessable Entity") {
+		return http.StatusUnprocessableEntity
+	}
+	return 0
+}
+
+// ClassifyError maps an error returned by a command to a CommandError with
+// the matching exit code and a message explaining the failure
+func ClassifyError(err error) *CommandError {
+	if e, ok := err.(*CommandError); ok {
+		return e
+	}
+	// errors of the login setup are passed through the http client
+	if e, ok := unwrapURLError(err).(*CommandError); ok {
+		return e
+	}
+	if _, ok := err.(*output.UsageError); ok {
+		return &CommandError{ExitUsage, err}
+	}
+
+	switch code := statusCode(err); code {
+	case http.StatusUnauthorized:
+		return &CommandError{ExitAuth, fmt.Errorf("authentication failed, the token may be expired or revoked, check it with 'tea login verify': %v", unwrapURLError(err))}
+	case http.StatusForbidden:
+		return &CommandError{ExitAuth, fmt.Errorf("permission denied: %v", unwrapURLError(err))}
+	case http.StatusNotFound:
+		return &CommandError{ExitNotFound, fmt.Errorf("not found, the resource does not exist or is not visible to the login: %v", err)}
+	case http.StatusConflict:
+		return &CommandError{ExitConflict, fmt.Errorf("conflict with an existing resource: %v", err)}
+	case http.StatusUnprocessableEntity:
+		return &CommandError{ExitUsage, fmt.Errorf("invalid input: %v", err)}
+	}
+
+	if _, ok := err.(*url.Error); ok {
+		return &CommandError{ExitNetwork, fmt.Errorf("could not reach the server: %v", err)}
+	}
+	if _, ok := err.(net.Error); ok {
+		return &CommandError{ExitNetwork, fmt.Errorf("could not reach the server: %v", err)}
+	}
+	return &CommandError{ExitError, err}
+}
+
+func unwrapURLError(err error) error {
+	if e, ok := err.(*url.Error); ok {
+		return e.Err
+	}
+	return err
+}
+
+// authTransport turns unauthorized responses into errors, the SDK only
+// keeps the status of a few responses and 401 is none of them
+type authTransport struct {
+	base http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	defer resp.Body.Close()
+	data, _ := ioutil.ReadAll(resp.Body)
+	return nil, responseError(resp.StatusCode, data)
+}
+
+// errorTransport fails all requests, it is used by clients of logins
+// whose setup failed
+type errorTransport struct {
+	err error
+}
+
+func (t errorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, &CommandError{ExitError, t.err}
+}
+
+// SetUsageErrorHandler reports flag parsing errors of the app and all its
+// commands as usage errors
+func SetUsageErrorHandler(app *cli.App) {
+	app.OnUsageError = onUsageError
+	setUsageErrorHandler(app.Commands)
+}
+
+func setUsageErrorHandler(cmds []cli.Command) {
+	for i := range cmds {
+		cmds[i].OnUsageError = onUsageError
+		setUsageErrorHandler(cmds[i].Subcommands)
+	}
+}
+
+func onUsageError(ctx *cli.Context, err error, isSubcommand bool) error {
+	switch {
+	case isSubcommand:
+		cli.ShowSubcommandHelp(ctx)
+	case ctx.Command.Name != "":
+		cli.ShowCommandHelp(ctx, ctx.Command.Name)
+	default:
+		cli.ShowAppHelp(ctx)
+	}
+	return &CommandError{ExitUsage, err}
+}
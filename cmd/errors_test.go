@@ -0,0 +1,68 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+
+	"code.gitea.io/tea/modules/output"
+)
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"nil", nil, 0},
+		{"other error", errors.New("failed"), 0},
+		{"response", responseError(500, []byte(`{"message":"internal error"}`)), 500},
+		{"wrapped response", &url.Error{Op: "Get", URL: "https://gitea.com", Err: responseError(401, nil)}, 401},
+		{"SDK forbidden", errors.New("403 Forbidden"), 403},
+		{"SDK not found", errors.New("404 Not Found"), 404},
+		{"SDK invalid input", errors.New("422 Unprocessable Entity: name is required"), 422},
+	}
+
+	for _, tt := range tests {
+		if actual := statusCode(tt.err); actual != tt.expected {
+			t.Errorf("%s: statusCode() = %d, expected %d", tt.name, actual, tt.expected)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	netErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"other error", errors.New("failed"), ExitError},
+		{"usage", usageError("name is required"), ExitUsage},
+		{"not found", notFoundError("label bug does not exist"), ExitNotFound},
+		{"conflict", conflictError("login name has already been used"), ExitConflict},
+		{"wrapped command error", &url.Error{Op: "Get", URL: "https://gitea.com", Err: &CommandError{ExitAuth, errors.New("no token")}}, ExitAuth},
+		{"output option", (output.Options{Format: "xml"}).Validate(), ExitUsage},
+		{"unauthorized", responseError(401, nil), ExitAuth},
+		{"forbidden", responseError(403, nil), ExitAuth},
+		{"SDK forbidden", errors.New("403 Forbidden"), ExitAuth},
+		{"not found response", responseError(404, nil), ExitNotFound},
+		{"conflict response", responseError(409, nil), ExitConflict},
+		{"invalid input", responseError(422, []byte("[]")), ExitUsage},
+		{"server error", responseError(500, nil), ExitError},
+		{"unreachable", &url.Error{Op: "Get", URL: "https://gitea.com", Err: netErr}, ExitNetwork},
+		{"network", netErr, ExitNetwork},
+	}
+
+	for _, tt := range tests {
+		if actual := ClassifyError(tt.err).Code; actual != tt.expected {
+			t.Errorf("%s: ClassifyError(%v) has exit code %d, expected %d", tt.name, tt.err, actual, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import "github.com/urfave/cli"
+
+// LoginFlag provides flag to specify tea login profile
+var LoginFlag = cli.StringFlag{
+	Name:   "login, l",
+	EnvVar: "TEA_LOGIN",
+	Usage:  "Indicate one login, optional when inside a gitea repository",
+}
+
+// RepoFlag provides flag to specify repository
+var RepoFlag = cli.StringFlag{
+	Name:  "repo, r",
+	Usage: "Indicate one repository, optional when inside a gitea repository",
+}
+
+// RemoteFlag provides flag to specify remote repository
+var RemoteFlag = cli.StringFlag{
+	Name:  "remote, R",
+	Usage: "Set a specific remote repository, is optional if not set use git default ones",
+}
+
+// LoginRepoFlags defines the login, repo and remote flags shared by
+// all commands operating on a repository
+var LoginRepoFlags = []cli.Flag{
+	LoginFlag,
+	RepoFlag,
+	RemoteFlag,
+}
+
+// OutputFlags defines the flags selecting the output format of listings
+var OutputFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "output, o",
+		Usage: "Output format: table, simple, csv, tsv, json or yaml",
+	},
+	cli.StringFlag{
+		Name:  "format",
+		Usage: "Go template applied to every item, e.g. '{{.Index}} {{.Title}}'",
+	},
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/utils"
+
+	"github.com/urfave/cli"
+)
+
+// editorCommentPrefix marks the lines of an editor template which are
+// removed afterwards, it is an HTML comment so Markdown headings survive
+const editorCommentPrefix = "<!--"
+
+// isInteractive reports whether missing input can be asked for, which
+// is only done when stdin is a terminal
+func isInteractive() bool {
+	return utils.IsTerminal(os.Stdin)
+}
+
+// editBody opens the editor to write a multi-line body
+func editBody(initial, what string) (string, error) {
+	content := fmt.Sprintf("%s\n\n%s Write the %s above. Lines starting with %s are removed. -->\n",
+		initial, editorCommentPrefix, what, editorCommentPrefix)
+	return utils.OpenEditor(content, editorCommentPrefix)
+}
+
+// issueMeta holds the labels, milestone and assignees of a new issue or
+// pull request by name
+type issueMeta struct {
+	Assignees []string
+	Milestone string
+	Labels    []string
+}
+
+// getIssueMeta reads the meta flags, with prompt the ones which are not
+// set are chosen from the options of the repository. Commands only prompt
+// when they asked for required input anyway, so a complete command line
+// is never interrupted.
+func getIssueMeta(ctx *cli.Context, client *gitea.Client, owner, repo string, prompt bool) (issueMeta, error) {
+	meta := issueMeta{
+		Assignees: splitList(ctx.String("assignees")),
+		Milestone: ctx.String("milestone"),
+		Labels:    splitList(ctx.String("labels")),
+	}
+	if !prompt {
+		return meta, nil
+	}
+
+	if !ctx.IsSet("labels") {
+		labels, err := client.ListRepoLabels(owner, repo)
+		if err != nil {
+			return meta, err
+		}
+		names := make([]string, 0, len(labels))
+		for _, l := range labels {
+			names = append(names, l.Name)
+		}
+		if len(names) > 0 {
+			if meta.Labels, err = utils.Select("Labels", names, true); err != nil {
+				return meta, err
+			}
+		}
+	}
+
+	if !ctx.IsSet("milestone") {
+		milestones, err := client.ListRepoMilestones(owner, repo)
+		if err != nil {
+			return meta, err
+		}
+		var names []string
+		for _, m := range milestones {
+			if m.State == gitea.StateOpen {
+				names = append(names, m.Title)
+			}
+		}
+		if len(names) > 0 {
+			selected, err := utils.Select("Milestone", names, false)
+			if err != nil {
+				return meta, err
+			}
+			if len(selected) > 0 {
+				meta.Milestone = selected[0]
+			}
+		}
+	}
+
+	if !ctx.IsSet("assignees") {
+		// listing collaborators needs push access, without it only the
+		// owner is offered
+		users, _ := client.ListCollaborators(owner, repo)
+		// the owner is no collaborator of its own repository, but
+		// organizations can not be assigned
+		var names []string
+		if _, err := client.GetOrg(owner); err != nil {
+			names = append(names, owner)
+		}
+		for _, u := range users {
+			if !strings.EqualFold(u.UserName, owner) {
+				names = append(names, u.UserName)
+			}
+		}
+		if len(names) > 0 {
+			var err error
+			if meta.Assignees, err = utils.Select("Assignees", names, true); err != nil {
+				return meta, err
+			}
+		}
+	}
+
+	return meta, nil
+}
+
+// resolve maps the names of the meta data to the IDs the API expects
+func (meta issueMeta) resolve(login *Login, owner, repo string) (milestone int64, labels []int64, err error) {
+	if milestone, err = resolveMilestoneID(login, owner, repo, meta.Milestone); err != nil {
+		return 0, nil, err
+	}
+	if labels, err = resolveLabelIDs(login.Client(), owner, repo, meta.Labels); err != nil {
+		return 0, nil, err
+	}
+	return milestone, labels, nil
+}
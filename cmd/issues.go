@@ -6,12 +6,13 @@ package cmd
 
 import (
 	"fmt"
-	"log"
-	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/output"
+	"code.gitea.io/tea/modules/utils"
 
 	"github.com/urfave/cli"
 )
@@ -25,17 +26,13 @@ var CmdIssues = cli.Command{
 	Subcommands: []cli.Command{
 		CmdIssuesList,
 		CmdIssuesCreate,
+		CmdIssuesEdit,
+		CmdIssuesClose,
+		CmdIssuesReopen,
+		CmdIssuesStart,
+		CmdIssuesStop,
 	},
-	Flags: []cli.Flag{
-		cli.StringFlag{
-			Name:  "login, l",
-			Usage: "Indicate one login, optional when inside a gitea repository",
-		},
-		cli.StringFlag{
-			Name:  "repo, r",
-			Usage: "Indicate one repository, optional when inside a gitea repository",
-		},
-	},
+	Flags: append(append(listFlags, OutputFlags...), LoginRepoFlags...),
 }
 
 // CmdIssuesList represents a sub command of issues to list issues
@@ -44,23 +41,23 @@ var CmdIssuesList = cli.Command{
 	Usage:       "List issues of the repository",
 	Description: `List issues of the repository`,
 	Action:      runIssuesList,
+	Flags:       append(append(listFlags, OutputFlags...), LoginRepoFlags...),
 }
 
 func runIssues(ctx *cli.Context) error {
-	if len(os.Args) == 3 {
-		return runIssueDetail(ctx, os.Args[2])
+	if ctx.Args().Present() {
+		return runIssueDetail(ctx, ctx.Args().First())
 	}
 	return runIssuesList(ctx)
 }
 
 func runIssueDetail(ctx *cli.Context, index string) error {
-	login, owner, repo := initCommand(ctx)
-
-	if strings.HasPrefix(index, "#") {
-		index = index[1:]
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
 	}
 
-	idx, err := strconv.ParseInt(index, 10, 64)
+	idx, err := argToIndex(index)
 	if err != nil {
 		return err
 	}
@@ -70,41 +67,68 @@ func runIssueDetail(ctx *cli.Context, index string) error {
 		return err
 	}
 
-	fmt.Printf("#%d %s\n%s created %s\n\n%s", issue.Index,
+	if isStructuredOutput(ctx) {
+		return printItem(ctx, issue, issuesTable([]*gitea.Issue{issue}))
+	}
+
+	fmt.Printf("#%d %s\n%s created %s\n\n%s\n", issue.Index,
 		issue.Title,
 		issue.Poster.UserName,
 		issue.Created.Format("2006-01-02 15:04:05"),
 		issue.Body,
 	)
+
+	if issue.Comments > 0 {
+		comments, err := login.Client().ListIssueComments(owner, repo, idx)
+		if err != nil {
+			return err
+		}
+		printComments(comments)
+	}
 	return nil
 }
 
 func runIssuesList(ctx *cli.Context) error {
-	login, owner, repo := initCommand(ctx)
+	opt, err := getListOptions(ctx)
+	if err != nil {
+		return err
+	}
 
-	issues, err := login.Client().ListRepoIssues(owner, repo, gitea.ListIssueOption{
-		Page:  0,
-		State: string(gitea.StateOpen),
-	})
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
 
+	issues, err := listRepoIssues(login, owner, repo, opt)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	if len(issues) == 0 {
+	if len(issues) == 0 && !isStructuredOutput(ctx) {
 		fmt.Println("No issues left")
 		return nil
 	}
 
+	return printTable(ctx, issuesTable(issues))
+}
+
+func issuesTable(issues []*gitea.Issue) *output.Table {
+	t := &output.Table{
+		Columns: []string{"index", "state", "author", "updated", "labels", "milestone", "title"},
+		Items:   issues,
+	}
 	for _, issue := range issues {
-		name := issue.Poster.FullName
-		if len(name) == 0 {
-			name = issue.Poster.UserName
-		}
-		fmt.Printf("#%d\t%s\t%s\t%s\n", issue.Index, name, issue.Updated.Format("2006-01-02 15:04:05"), issue.Title)
+		t.AddRow(
+			strconv.FormatInt(issue.Index, 10),
+			string(issue.State),
+			formatUser(issue.Poster),
+			formatTime(issue.Updated),
+			formatLabels(issue.Labels),
+			formatMilestone(issue.Milestone),
+			issue.Title,
+		)
 	}
-
-	return nil
+	return t
 }
 
 // CmdIssuesCreate represents a sub command of issues to create issue
@@ -113,7 +137,7 @@ var CmdIssuesCreate = cli.Command{
 	Usage:       "Create an issue on repository",
 	Description: `Create an issue on repository`,
 	Action:      runIssuesCreate,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		cli.StringFlag{
 			Name:  "title, t",
 			Usage: "issue title to create",
@@ -122,66 +146,395 @@ var CmdIssuesCreate = cli.Command{
 			Name:  "body, b",
 			Usage: "issue body to create",
 		},
+	}, append(issueMetaFlags, LoginRepoFlags...)...),
+}
+
+// issueMetaFlags are the flags shared by the commands which create or
+// modify issues
+var issueMetaFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "assignees, a",
+		Usage: "Comma-separated list of usernames to assign",
+	},
+	cli.StringFlag{
+		Name:  "deadline, D",
+		Usage: "Deadline of the issue in the format YYYY-MM-DD",
+	},
+	cli.StringFlag{
+		Name:  "milestone, m",
+		Usage: "Milestone name to assign",
+	},
+	cli.StringFlag{
+		Name:  "labels, L",
+		Usage: "Comma-separated list of label names to assign",
 	},
 }
 
-func initCommand(ctx *cli.Context) (*Login, string, string) {
+func initCommand(ctx *cli.Context) (*Login, string, string, error) {
 	err := loadConfig(yamlConfigPath)
 	if err != nil {
-		log.Fatal("load config file failed", yamlConfigPath)
+		return nil, "", "", err
 	}
 
 	var login *Login
-	if loginFlag := getGlobalFlag(ctx, "login"); loginFlag == "" {
-		login, err = getActiveLogin()
-		if err != nil {
-			log.Fatal(err)
-		}
-	} else {
+	if loginFlag := getGlobalFlag(ctx, "login"); loginFlag != "" {
 		login = getLoginByName(loginFlag)
 		if login == nil {
-			log.Fatal("indicated login name", loginFlag, "does not exist")
+			return nil, "", "", notFoundError("login %s does not exist", loginFlag)
 		}
 	}
 
 	repoPath := getGlobalFlag(ctx, "repo")
 	if repoPath == "" {
-		login, repoPath, err = curGitRepoPath()
+		login, repoPath, err = curGitRepoPath(getGlobalFlag(ctx, "remote"), login)
+		if err != nil {
+			return nil, "", "", err
+		}
+	} else if login == nil {
+		login, err = getActiveLogin()
 		if err != nil {
-			log.Fatal(err.Error())
+			return nil, "", "", err
 		}
 	}
+	if err = login.checkLogin(); err != nil {
+		return nil, "", "", err
+	}
 
 	owner, repo := splitRepo(repoPath)
-	return login, owner, repo
+	return login, owner, repo, nil
 }
 
+// initLoginCommand loads the login of commands which do not operate on a
+// single repository, given by --login or the active one
+func initLoginCommand(ctx *cli.Context) (*Login, error) {
+	if err := loadConfig(yamlConfigPath); err != nil {
+		return nil, err
+	}
+
+	var login *Login
+	if loginFlag := getGlobalFlag(ctx, "login"); loginFlag != "" {
+		if login = getLoginByName(loginFlag); login == nil {
+			return nil, notFoundError("login %s does not exist", loginFlag)
+		}
+	} else {
+		var err error
+		if login, err = getActiveLogin(); err != nil {
+			return nil, err
+		}
+	}
+	return login, login.checkLogin()
+}
+
+// initRepoArgCommand is initCommand for commands taking the repository as
+// an optional <owner>/<repo> argument
+func initRepoArgCommand(ctx *cli.Context) (*Login, string, string, error) {
+	if !ctx.Args().Present() {
+		return initCommand(ctx)
+	}
+	owner, repo := splitRepo(ctx.Args().First())
+	if owner == "" || repo == "" {
+		return nil, "", "", usageError("invalid repository %s, expected <owner>/<repo>", ctx.Args().First())
+	}
+	login, err := initLoginCommand(ctx)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return login, owner, repo, nil
+}
+
+// getGlobalFlag returns the first non-empty value of flag, looking at the
+// command itself first and then at its parent commands
 func getGlobalFlag(ctx *cli.Context, flag string) string {
-	var val = ctx.String(flag)
-	if val == "" {
-		return ctx.GlobalString(flag)
+	for c := ctx; c != nil; c = c.Parent() {
+		if val := c.String(flag); val != "" {
+			return val
+		}
 	}
-	return val
+	return ""
 }
 
 func runIssuesCreate(ctx *cli.Context) error {
-	login, owner, repo := initCommand(ctx)
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+	client := login.Client()
 
-	_, err := login.Client().CreateIssue(owner, repo, gitea.CreateIssueOption{
-		Title: ctx.String("title"),
-		Body:  ctx.String("body"),
-		// TODO:
-		//Assignee  string   `json:"assignee"`
-		//Assignees []string `json:"assignees"`
-		//Deadline *time.Time `json:"due_date"`
-		//Milestone int64 `json:"milestone"`
-		//Labels []int64 `json:"labels"`
-		//Closed bool    `json:"closed"`
+	title, body := ctx.String("title"), ctx.String("body")
+	prompted := title == ""
+	if prompted {
+		if !isInteractive() {
+			return usageError("title is required")
+		}
+		var err error
+		if title, err = utils.PromptRequired("Title", ""); err != nil {
+			return err
+		}
+	}
+	if body == "" && isInteractive() {
+		var err error
+		if body, err = editBody("", "description of the issue"); err != nil {
+			return err
+		}
+	}
+
+	deadline, err := parseDeadline(ctx.String("deadline"))
+	if err != nil {
+		return err
+	}
+
+	meta, err := getIssueMeta(ctx, client, owner, repo, prompted)
+	if err != nil {
+		return err
+	}
+	milestone, labels, err := meta.resolve(login, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	issue, err := client.CreateIssue(owner, repo, gitea.CreateIssueOption{
+		Title:     title,
+		Body:      body,
+		Assignees: meta.Assignees,
+		Deadline:  deadline,
+		Milestone: milestone,
+		Labels:    labels,
 	})
 
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
+	fmt.Printf("#%d %s\n", issue.Index, issue.Title)
 	return nil
 }
+
+// CmdIssuesEdit represents a sub command of issues to edit an issue
+var CmdIssuesEdit = cli.Command{
+	Name:        "edit",
+	Usage:       "Edit an issue of the repository",
+	Description: `Edit an issue of the repository`,
+	ArgsUsage:   "<issue index>",
+	Action:      runIssuesEdit,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "title, t",
+			Usage: "new issue title",
+		},
+		cli.StringFlag{
+			Name:  "body, b",
+			Usage: "new issue body",
+		},
+		cli.StringFlag{
+			Name:  "add-labels",
+			Usage: "Comma-separated list of label names to add",
+		},
+		cli.StringFlag{
+			Name:  "remove-labels",
+			Usage: "Comma-separated list of label names to remove",
+		},
+	}, append(issueMetaFlags, LoginRepoFlags...)...),
+}
+
+func runIssuesEdit(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return usageError("issue index is required")
+	}
+	idx, err := argToIndex(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+	client := login.Client()
+
+	opt := gitea.EditIssueOption{
+		Title: ctx.String("title"),
+	}
+	if ctx.IsSet("body") {
+		opt.Body = gitea.String(ctx.String("body"))
+	}
+	if ctx.IsSet("assignees") {
+		opt.Assignees = splitList(ctx.String("assignees"))
+		if opt.Assignees == nil {
+			opt.Assignees = []string{}
+		}
+	}
+	if opt.Deadline, err = parseDeadline(ctx.String("deadline")); err != nil {
+		return err
+	}
+	if ctx.IsSet("milestone") {
+		milestone, err := resolveMilestoneID(login, owner, repo, ctx.String("milestone"))
+		if err != nil {
+			return err
+		}
+		opt.Milestone = gitea.Int64(milestone)
+	}
+
+	issue, err := client.EditIssue(owner, repo, idx, opt)
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsSet("labels") {
+		labels, err := resolveLabelIDs(client, owner, repo, splitList(ctx.String("labels")))
+		if err != nil {
+			return err
+		}
+		if _, err = client.ReplaceIssueLabels(owner, repo, idx, gitea.IssueLabelsOption{Labels: labels}); err != nil {
+			return err
+		}
+	}
+
+	if addLabels := splitList(ctx.String("add-labels")); len(addLabels) > 0 {
+		labels, err := resolveLabelIDs(client, owner, repo, addLabels)
+		if err != nil {
+			return err
+		}
+		if _, err = client.AddIssueLabels(owner, repo, idx, gitea.IssueLabelsOption{Labels: labels}); err != nil {
+			return err
+		}
+	}
+
+	if removeLabels := splitList(ctx.String("remove-labels")); len(removeLabels) > 0 {
+		labels, err := resolveLabelIDs(client, owner, repo, removeLabels)
+		if err != nil {
+			return err
+		}
+		for _, label := range labels {
+			if err = client.DeleteIssueLabel(owner, repo, idx, label); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Printf("#%d %s\n", issue.Index, issue.Title)
+	return nil
+}
+
+// CmdIssuesClose represents a sub command of issues to close issues
+var CmdIssuesClose = cli.Command{
+	Name:        "close",
+	Usage:       "Close one or more issues",
+	Description: `Close one or more issues`,
+	ArgsUsage:   "<issue index> [<issue index>...]",
+	Action: func(ctx *cli.Context) error {
+		return runIssuesState(ctx, gitea.StateClosed)
+	},
+	Flags: LoginRepoFlags,
+}
+
+// CmdIssuesReopen represents a sub command of issues to reopen issues
+var CmdIssuesReopen = cli.Command{
+	Name:        "reopen",
+	Usage:       "Reopen one or more issues",
+	Description: `Reopen one or more issues`,
+	ArgsUsage:   "<issue index> [<issue index>...]",
+	Action: func(ctx *cli.Context) error {
+		return runIssuesState(ctx, gitea.StateOpen)
+	},
+	Flags: LoginRepoFlags,
+}
+
+func runIssuesState(ctx *cli.Context, state gitea.StateType) error {
+	if !ctx.Args().Present() {
+		return usageError("issue index is required")
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+	client := login.Client()
+
+	for _, arg := range ctx.Args() {
+		idx, err := argToIndex(arg)
+		if err != nil {
+			return err
+		}
+
+		issue, err := client.EditIssue(owner, repo, idx, gitea.EditIssueOption{
+			State: gitea.String(string(state)),
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("#%d %s: %s\n", issue.Index, issue.State, issue.Title)
+	}
+
+	return nil
+}
+
+// argToIndex parses an issue index, which may be prefixed by a '#'
+func argToIndex(arg string) (int64, error) {
+	return strconv.ParseInt(strings.TrimPrefix(arg, "#"), 10, 64)
+}
+
+// splitList splits a comma-separated flag value, ignoring empty items
+func splitList(val string) []string {
+	var list []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parseDeadline parses a YYYY-MM-DD date, an empty string means no deadline
+func parseDeadline(val string) (*time.Time, error) {
+	if val == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", val, time.Local)
+	if err != nil {
+		return nil, usageError("invalid deadline %s, expected YYYY-MM-DD", val)
+	}
+	return &t, nil
+}
+
+// resolveLabelIDs maps label names to the IDs of the repository labels
+func resolveLabelIDs(client *gitea.Client, owner, repo string, names []string) ([]int64, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	labels, err := client.ListRepoLabels(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(names))
+	for _, name := range names {
+		var found bool
+		for _, label := range labels {
+			if strings.EqualFold(label.Name, name) {
+				ids = append(ids, label.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, notFoundError("label %s does not exist", name)
+		}
+	}
+	return ids, nil
+}
+
+// resolveMilestoneID maps a milestone name to its ID, an empty name maps
+// to 0. Closed milestones are found as well.
+func resolveMilestoneID(login *Login, owner, repo, name string) (int64, error) {
+	if name == "" {
+		return 0, nil
+	}
+
+	m, err := getMilestone(login, owner, repo, name)
+	if err != nil {
+		return 0, err
+	}
+	return m.ID, nil
+}
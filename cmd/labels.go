@@ -0,0 +1,288 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/tea/modules/output"
+
+	"github.com/urfave/cli"
+)
+
+// CmdLabels represents to operate the labels of a repository
+var CmdLabels = cli.Command{
+	Name:        "labels",
+	Usage:       "Manage the labels of the repository",
+	Description: `List, create, edit and delete labels, or sync them from a file or another repository`,
+	Action:      runLabelsList,
+	Subcommands: []cli.Command{
+		CmdLabelsList,
+		CmdLabelsCreate,
+		CmdLabelsEdit,
+		CmdLabelsDelete,
+		CmdLabelsSync,
+		CmdLabelsCopy,
+	},
+	Flags: append(OutputFlags, LoginRepoFlags...),
+}
+
+// label is a label of a repository including its description, which the
+// SDK does not know about
+type label struct {
+	ID          int64  `json:"id,omitempty" yaml:"-"`
+	Name        string `json:"name" yaml:"name"`
+	Color       string `json:"color" yaml:"color"`
+	Description string `json:"description" yaml:"description,omitempty"`
+}
+
+// editLabelOption changes the fields of a label which are set
+type editLabelOption struct {
+	Name        *string `json:"name,omitempty"`
+	Color       *string `json:"color,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+var colorRe = regexp.MustCompile("^#?[0-9a-fA-F]{6}$")
+
+// normalizeColor validates a color and returns it in the #rrggbb form
+// Gitea expects
+func normalizeColor(color string) (string, error) {
+	if !colorRe.MatchString(color) {
+		return "", usageError("invalid color %s, expected a hex color like #ee0701", color)
+	}
+	return "#" + strings.ToLower(strings.TrimPrefix(color, "#")), nil
+}
+
+func listLabels(login *Login, owner, repo string) ([]*label, error) {
+	labels := make([]*label, 0, 10)
+	return labels, login.getParsedResponse("GET",
+		fmt.Sprintf("/repos/%s/%s/labels", owner, repo), nil, nil, &labels)
+}
+
+func createLabel(login *Login, owner, repo string, l *label) (*label, error) {
+	body, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	created := new(label)
+	return created, login.getParsedResponse("POST",
+		fmt.Sprintf("/repos/%s/%s/labels", owner, repo), jsonHeader, bytes.NewReader(body), created)
+}
+
+func editLabel(login *Login, owner, repo string, id int64, opt editLabelOption) (*label, error) {
+	body, err := json.Marshal(opt)
+	if err != nil {
+		return nil, err
+	}
+	edited := new(label)
+	return edited, login.getParsedResponse("PATCH",
+		fmt.Sprintf("/repos/%s/%s/labels/%d", owner, repo, id), jsonHeader, bytes.NewReader(body), edited)
+}
+
+func deleteLabel(login *Login, owner, repo string, id int64) error {
+	_, err := login.getResponse("DELETE", fmt.Sprintf("/repos/%s/%s/labels/%d", owner, repo, id), nil, nil)
+	return err
+}
+
+// findLabel looks a label up by its name, ignoring the case
+func findLabel(labels []*label, name string) *label {
+	for _, l := range labels {
+		if strings.EqualFold(l.Name, name) {
+			return l
+		}
+	}
+	return nil
+}
+
+// getLabel fetches the label of a repository by name
+func getLabel(login *Login, owner, repo, name string) (*label, error) {
+	if name == "" {
+		return nil, usageError("label name is required")
+	}
+	labels, err := listLabels(login, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	l := findLabel(labels, name)
+	if l == nil {
+		return nil, notFoundError("label %s does not exist", name)
+	}
+	return l, nil
+}
+
+// CmdLabelsList represents a sub command of labels to list them
+var CmdLabelsList = cli.Command{
+	Name:  "ls",
+	Usage: "List the labels of the repository",
+	Description: `List the labels of the repository. With --output yaml the list has the format
+of 'tea labels sync --file'.`,
+	Action: runLabelsList,
+	Flags:  append(OutputFlags, LoginRepoFlags...),
+}
+
+func runLabelsList(ctx *cli.Context) error {
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	labels, err := listLabels(login, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	if len(labels) == 0 && !isStructuredOutput(ctx) {
+		fmt.Println("No labels")
+		return nil
+	}
+
+	t := &output.Table{
+		Columns: []string{"id", "name", "color", "description"},
+		Items:   labels,
+	}
+	for _, l := range labels {
+		t.AddRow(strconv.FormatInt(l.ID, 10), l.Name, l.Color, l.Description)
+	}
+	return printTable(ctx, t)
+}
+
+// CmdLabelsCreate represents a sub command of labels to create one
+var CmdLabelsCreate = cli.Command{
+	Name:        "create",
+	Usage:       "Create a label",
+	Description: `Create a label`,
+	ArgsUsage:   "<name>",
+	Action:      runLabelsCreate,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "color, c",
+			Usage: "Hex color of the label, e.g. #ee0701",
+		},
+		cli.StringFlag{
+			Name:  "description, d",
+			Usage: "Description of the label",
+		},
+	}, LoginRepoFlags...),
+}
+
+func runLabelsCreate(ctx *cli.Context) error {
+	name := ctx.Args().First()
+	if name == "" {
+		return usageError("label name is required")
+	}
+	if !ctx.IsSet("color") {
+		return usageError("--color is required")
+	}
+	color, err := normalizeColor(ctx.String("color"))
+	if err != nil {
+		return err
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	l, err := createLabel(login, owner, repo, &label{
+		Name:        name,
+		Color:       color,
+		Description: ctx.String("description"),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created label %s\n", l.Name)
+	return nil
+}
+
+// CmdLabelsEdit represents a sub command of labels to edit one
+var CmdLabelsEdit = cli.Command{
+	Name:        "edit",
+	Usage:       "Change a label",
+	Description: `Change the name, color or description of a label`,
+	ArgsUsage:   "<name>",
+	Action:      runLabelsEdit,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "name, n",
+			Usage: "New name of the label",
+		},
+		cli.StringFlag{
+			Name:  "color, c",
+			Usage: "Hex color of the label, e.g. #ee0701",
+		},
+		cli.StringFlag{
+			Name:  "description, d",
+			Usage: "Description of the label",
+		},
+	}, LoginRepoFlags...),
+}
+
+func runLabelsEdit(ctx *cli.Context) error {
+	var opt editLabelOption
+	if ctx.IsSet("name") {
+		name := ctx.String("name")
+		opt.Name = &name
+	}
+	if ctx.IsSet("color") {
+		color, err := normalizeColor(ctx.String("color"))
+		if err != nil {
+			return err
+		}
+		opt.Color = &color
+	}
+	if ctx.IsSet("description") {
+		description := ctx.String("description")
+		opt.Description = &description
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	l, err := getLabel(login, owner, repo, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	if l, err = editLabel(login, owner, repo, l.ID, opt); err != nil {
+		return err
+	}
+	fmt.Printf("Updated label %s\n", l.Name)
+	return nil
+}
+
+// CmdLabelsDelete represents a sub command of labels to delete one
+var CmdLabelsDelete = cli.Command{
+	Name:        "delete",
+	Usage:       "Delete a label",
+	Description: `Delete a label, it is removed from all issues and pull requests`,
+	ArgsUsage:   "<name>",
+	Action:      runLabelsDelete,
+	Flags:       LoginRepoFlags,
+}
+
+func runLabelsDelete(ctx *cli.Context) error {
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	l, err := getLabel(login, owner, repo, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	if err = deleteLabel(login, owner, repo, l.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted label %s\n", l.Name)
+	return nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/go-gitea/yaml"
+	"github.com/urfave/cli"
+)
+
+// labelsSyncFlags are shared by the commands updating all labels at once
+var labelsSyncFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "prune",
+		Usage: "Delete the labels which are not listed",
+	},
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Only print the changes without applying them",
+	},
+}
+
+// CmdLabelsSync represents a sub command of labels to sync them from a file
+var CmdLabelsSync = cli.Command{
+	Name:  "sync",
+	Usage: "Make the labels match a file",
+	Description: `Create and update the labels of the repository to match a YAML file listing
+their names, colors and descriptions, '-' reads it from stdin:
+
+   - name: bug
+     color: ee0701
+     description: Something is not working
+
+Labels are matched by name ignoring the case, so the case of a name can be
+changed as well. Labels which are not listed are kept unless --prune is given.
+'tea labels ls --output yaml' prints the labels of a repository in this format.`,
+	Action: runLabelsSync,
+	Flags: append(append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "file, f",
+			Usage: "YAML file with the labels",
+		},
+	}, labelsSyncFlags...), LoginRepoFlags...),
+}
+
+func runLabelsSync(ctx *cli.Context) error {
+	file := ctx.String("file")
+	if file == "" {
+		return usageError("--file is required")
+	}
+	labels, err := readLabelsFile(file)
+	if err != nil {
+		return err
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+	return syncLabels(login, owner, repo, labels, ctx.Bool("prune"), ctx.Bool("dry-run"))
+}
+
+// CmdLabelsCopy represents a sub command of labels to copy them from
+// another repository
+var CmdLabelsCopy = cli.Command{
+	Name:  "copy",
+	Usage: "Make the labels match the ones of another repository",
+	Description: `Create and update the labels of the repository to match the labels of another
+repository of the same login. Labels which the other repository does not have
+are kept unless --prune is given.`,
+	Action: runLabelsCopy,
+	Flags: append(append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "from",
+			Usage: "Repository to copy the labels from, <owner>/<repo>",
+		},
+	}, labelsSyncFlags...), LoginRepoFlags...),
+}
+
+func runLabelsCopy(ctx *cli.Context) error {
+	fromOwner, fromRepo := splitRepo(ctx.String("from"))
+	if fromOwner == "" || fromRepo == "" {
+		return usageError("--from <owner>/<repo> is required")
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	labels, err := listLabels(login, fromOwner, fromRepo)
+	if err != nil {
+		return err
+	}
+	return syncLabels(login, owner, repo, labels, ctx.Bool("prune"), ctx.Bool("dry-run"))
+}
+
+// readLabelsFile reads and validates a YAML list of labels
+func readLabelsFile(file string) ([]*label, error) {
+	var bs []byte
+	var err error
+	if file == "-" {
+		bs, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		bs, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var labels []*label
+	if err = yaml.Unmarshal(bs, &labels); err != nil {
+		return nil, fmt.Errorf("reading labels from %s failed: %v", file, err)
+	}
+
+	seen := make(map[string]bool, len(labels))
+	for i, l := range labels {
+		if l == nil || strings.TrimSpace(l.Name) == "" {
+			return nil, usageError("label %d in %s has no name", i+1, file)
+		}
+		key := strings.ToLower(l.Name)
+		if seen[key] {
+			return nil, usageError("label %s is listed twice in %s", l.Name, file)
+		}
+		seen[key] = true
+		if l.Color, err = normalizeColor(l.Color); err != nil {
+			return nil, fmt.Errorf("label %s: %v", l.Name, err)
+		}
+	}
+	return labels, nil
+}
+
+// labelChange is a change syncLabels makes to the labels of a repository
+type labelChange struct {
+	// action is create, update or delete
+	action string
+	// label is the label to create, or the existing one to update or delete
+	label *label
+	// name is the name of the label after the change
+	name string
+	edit editLabelOption
+}
+
+// diffLabels returns the changes turning the labels have into want in
+// the order of want, with prune the deletions of the other labels follow
+func diffLabels(have, want []*label, prune bool) ([]labelChange, error) {
+	var changes []labelChange
+	for _, w := range want {
+		color, err := normalizeColor(w.Color)
+		if err != nil {
+			return nil, fmt.Errorf("label %s: %v", w.Name, err)
+		}
+
+		existing := findLabel(have, w.Name)
+		if existing == nil {
+			changes = append(changes, labelChange{
+				action: "create",
+				label:  &label{Name: w.Name, Color: color, Description: w.Description},
+				name:   w.Name,
+			})
+			continue
+		}
+
+		var opt editLabelOption
+		if existing.Name != w.Name {
+			opt.Name = &w.Name
+		}
+		// the API returns colors without the leading #
+		if c, _ := normalizeColor(existing.Color); c != color {
+			opt.Color = &color
+		}
+		if existing.Description != w.Description {
+			opt.Description = &w.Description
+		}
+		if opt.Name == nil && opt.Color == nil && opt.Description == nil {
+			continue
+		}
+		changes = append(changes, labelChange{action: "update", label: existing, name: w.Name, edit: opt})
+	}
+
+	if prune {
+		for _, h := range have {
+			if findLabel(want, h.Name) == nil {
+				changes = append(changes, labelChange{action: "delete", label: h, name: h.Name})
+			}
+		}
+	}
+	return changes, nil
+}
+
+// syncLabels creates and updates the labels of a repository to match
+// want, with prune the other labels are deleted
+func syncLabels(login *Login, owner, repo string, want []*label, prune, dryRun bool) error {
+	have, err := listLabels(login, owner, repo)
+	if err != nil {
+		return err
+	}
+	changes, err := diffLabels(have, want, prune)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		if dryRun {
+			fmt.Printf("Would %s label %s\n", c.action, c.name)
+			continue
+		}
+		var done string
+		switch c.action {
+		case "create":
+			done = "Created"
+			_, err = createLabel(login, owner, repo, c.label)
+		case "update":
+			done = "Updated"
+			_, err = editLabel(login, owner, repo, c.label.ID, c.edit)
+		case "delete":
+			done = "Deleted"
+			err = deleteLabel(login, owner, repo, c.label.ID)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s label %s\n", done, c.name)
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("Labels are up to date")
+	}
+	return nil
+}
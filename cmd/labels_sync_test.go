@@ -0,0 +1,156 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeColor(t *testing.T) {
+	tests := []struct {
+		color    string
+		expected string
+		err      bool
+	}{
+		{"#ee0701", "#ee0701", false},
+		{"EE0701", "#ee0701", false},
+		{"#Ee0701", "#ee0701", false},
+		{"ee070", "", true},
+		{"#ee07011", "", true},
+		{"red", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		color, err := normalizeColor(tt.color)
+		if tt.err {
+			if err == nil {
+				t.Errorf("normalizeColor(%q) = %q, expected an error", tt.color, color)
+			}
+			continue
+		}
+		if err != nil || color != tt.expected {
+			t.Errorf("normalizeColor(%q) = %q, %v, expected %q", tt.color, color, err, tt.expected)
+		}
+	}
+}
+
+func TestDiffLabels(t *testing.T) {
+	have := []*label{
+		{ID: 1, Name: "bug", Color: "ee0701", Description: "Something is broken"},
+		{ID: 2, Name: "Feature", Color: "84b6eb"},
+		{ID: 3, Name: "wontfix", Color: "ffffff"},
+		{ID: 4, Name: "docs", Color: "cccccc", Description: "Documentation"},
+	}
+	want := []*label{
+		{Name: "bug", Color: "#EE0701", Description: "Something is broken"},
+		{Name: "feature", Color: "#84b6eb"},
+		{Name: "docs", Color: "#0075ca", Description: "Docs"},
+		{Name: "help wanted", Color: "128a0c", Description: "Need some help"},
+	}
+	rename, recolor, describe := "feature", "#0075ca", "Docs"
+
+	updates := []labelChange{
+		{action: "update", label: have[1], name: "feature", edit: editLabelOption{Name: &rename}},
+		{action: "update", label: have[3], name: "docs", edit: editLabelOption{Color: &recolor, Description: &describe}},
+		{action: "create", label: &label{Name: "help wanted", Color: "#128a0c", Description: "Need some help"}, name: "help wanted"},
+	}
+	tests := []struct {
+		name     string
+		have     []*label
+		want     []*label
+		prune    bool
+		expected []labelChange
+	}{
+		{"sync", have, want, false, updates},
+		{"prune", have, want, true, append(updates, labelChange{action: "delete", label: have[2], name: "wontfix"})},
+		{"up to date", have, have, true, nil},
+		{"prune all", have[:1], nil, true, []labelChange{{action: "delete", label: have[0], name: "bug"}}},
+		{"empty repository", nil, want[:1], true, []labelChange{
+			{action: "create", label: &label{Name: "bug", Color: "#ee0701", Description: "Something is broken"}, name: "bug"},
+		}},
+	}
+
+	for _, tt := range tests {
+		changes, err := diffLabels(tt.have, tt.want, tt.prune)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(changes, tt.expected) {
+			t.Errorf("%s: diffLabels() = %s, expected %s", tt.name, formatLabelChanges(changes), formatLabelChanges(tt.expected))
+		}
+	}
+
+	if _, err := diffLabels(have, []*label{{Name: "bug", Color: "red"}}, false); err == nil {
+		t.Error("expected an error for an invalid color")
+	}
+}
+
+func formatLabelChanges(changes []labelChange) string {
+	var s []string
+	for _, c := range changes {
+		s = append(s, c.action+" "+c.name)
+	}
+	return "[" + strings.Join(s, ", ") + "]"
+}
+
+func TestReadLabelsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tea-labels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []*label
+		err      string
+	}{
+		{
+			name: "valid",
+			content: `- name: bug
+  color: EE0701
+  description: Something is broken
+- name: feature
+  color: "#84b6eb"
+`,
+			expected: []*label{
+				{Name: "bug", Color: "#ee0701", Description: "Something is broken"},
+				{Name: "feature", Color: "#84b6eb"},
+			},
+		},
+		{name: "empty", content: ""},
+		{name: "no name", content: "- color: ee0701\n", err: "label 1 in"},
+		{name: "listed twice", content: "- name: bug\n  color: ee0701\n- name: Bug\n  color: ee0701\n", err: "listed twice"},
+		{name: "invalid color", content: "- name: bug\n  color: red\n", err: "label bug: invalid color red"},
+		{name: "no list", content: "name: bug\n", err: "reading labels"},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, strings.Replace(tt.name, " ", "-", -1)+".yml")
+		if err = ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		labels, err := readLabelsFile(path)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected error %q, got %v", tt.name, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if !reflect.DeepEqual(labels, tt.expected) {
+			t.Errorf("%s: readLabelsFile() = %v, expected %v", tt.name, labels, tt.expected)
+		}
+	}
+}
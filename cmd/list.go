@@ -0,0 +1,229 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+
+	"github.com/urfave/cli"
+)
+
+// listFlags are the filter flags shared by the issue and pull request lists
+var listFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "state, s",
+		Value: "open",
+		Usage: "Filter by state: open, closed or all",
+	},
+	cli.StringFlag{
+		Name:  "labels",
+		Usage: "Comma-separated list of label names, all of them have to match",
+	},
+	cli.StringFlag{
+		Name:  "milestone",
+		Usage: "Filter by milestone name",
+	},
+	cli.StringFlag{
+		Name:  "author",
+		Usage: "Filter by the username of the author",
+	},
+	cli.StringFlag{
+		Name:  "assignee",
+		Usage: "Filter by the username of an assignee",
+	},
+	cli.StringFlag{
+		Name:  "keyword, k",
+		Usage: "Filter by a keyword in title or body",
+	},
+	cli.IntFlag{
+		Name:  "limit",
+		Usage: "Maximum number of results, 0 lists all of them",
+	},
+}
+
+// listOptions holds the filters for listing issues and pull requests
+type listOptions struct {
+	gitea.ListIssueOption
+	Labels    []string
+	Milestone string
+	Author    string
+	Assignee  string
+	Keyword   string
+	Limit     int
+}
+
+func getListOptions(ctx *cli.Context) (listOptions, error) {
+	opt := listOptions{
+		ListIssueOption: gitea.ListIssueOption{
+			Page:  1,
+			State: strings.ToLower(ctx.String("state")),
+		},
+		Labels:    splitList(ctx.String("labels")),
+		Milestone: ctx.String("milestone"),
+		Author:    ctx.String("author"),
+		Assignee:  ctx.String("assignee"),
+		Keyword:   ctx.String("keyword"),
+		Limit:     ctx.Int("limit"),
+	}
+
+	switch opt.State {
+	case "":
+		opt.State = string(gitea.StateOpen)
+	case string(gitea.StateOpen), string(gitea.StateClosed), "all":
+	default:
+		return opt, usageError("unknown state %s, expected open, closed or all", opt.State)
+	}
+	if opt.Limit < 0 {
+		return opt, usageError("invalid limit %d", opt.Limit)
+	}
+	return opt, nil
+}
+
+// query returns the query string for the current page of the list
+func (opt *listOptions) query() string {
+	q := url.Values{}
+	q.Set("page", strconv.Itoa(opt.Page))
+	q.Set("state", opt.State)
+	return q.Encode()
+}
+
+// issueQuery returns the query string for the current page of an issue
+// list, the issue API filters by labels, milestone and keyword as well
+func (opt *listOptions) issueQuery() string {
+	q := url.Values{}
+	q.Set("page", strconv.Itoa(opt.Page))
+	q.Set("state", opt.State)
+	if len(opt.Labels) > 0 {
+		q.Set("labels", strings.Join(opt.Labels, ","))
+	}
+	if opt.Milestone != "" {
+		q.Set("milestones", opt.Milestone)
+	}
+	if opt.Keyword != "" {
+		q.Set("q", opt.Keyword)
+	}
+	return q.Encode()
+}
+
+// limitReached reports whether n results are enough to satisfy the limit
+func (opt *listOptions) limitReached(n int) bool {
+	return opt.Limit > 0 && n >= opt.Limit
+}
+
+// matches reports whether an issue or pull request passes all the
+// filters, the ones sent to the server are checked again for older
+// servers ignoring them
+func (opt *listOptions) matches(poster *gitea.User, assignees []*gitea.User, labels []*gitea.Label, milestone *gitea.Milestone, title, body string) bool {
+	if opt.Author != "" && (poster == nil || !strings.EqualFold(poster.UserName, opt.Author)) {
+		return false
+	}
+
+	if opt.Assignee != "" {
+		var found bool
+		for _, a := range assignees {
+			if a != nil && strings.EqualFold(a.UserName, opt.Assignee) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, name := range opt.Labels {
+		var found bool
+		for _, l := range labels {
+			if l != nil && strings.EqualFold(l.Name, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if opt.Milestone != "" && (milestone == nil || !strings.EqualFold(milestone.Title, opt.Milestone)) {
+		return false
+	}
+
+	if opt.Keyword != "" {
+		keyword := strings.ToLower(opt.Keyword)
+		if !strings.Contains(strings.ToLower(title), keyword) &&
+			!strings.Contains(strings.ToLower(body), keyword) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// listRepoIssues fetches the issues of a repository page by page until
+// all of them are retrieved or the limit is reached
+func listRepoIssues(login *Login, owner, repo string, opt listOptions) ([]*gitea.Issue, error) {
+	var result []*gitea.Issue
+	for ; ; opt.Page++ {
+		issues := make([]*gitea.Issue, 0, 10)
+		err := login.getParsedResponse("GET",
+			fmt.Sprintf("/repos/%s/%s/issues?%s", owner, repo, opt.issueQuery()),
+			nil, nil, &issues)
+		if err != nil {
+			return nil, err
+		}
+		if len(issues) == 0 {
+			return result, nil
+		}
+
+		for _, issue := range issues {
+			if issue.PullRequest != nil {
+				continue
+			}
+			if !opt.matches(issue.Poster, issue.Assignees, issue.Labels, issue.Milestone, issue.Title, issue.Body) {
+				continue
+			}
+			result = append(result, issue)
+			if opt.limitReached(len(result)) {
+				return result, nil
+			}
+		}
+	}
+}
+
+// listRepoPullRequests fetches the pull requests of a repository page by
+// page until all of them are retrieved or the limit is reached
+func listRepoPullRequests(login *Login, owner, repo string, opt listOptions) ([]*gitea.PullRequest, error) {
+	var result []*gitea.PullRequest
+	for ; ; opt.Page++ {
+		prs := make([]*gitea.PullRequest, 0, 10)
+		err := login.getParsedResponse("GET",
+			fmt.Sprintf("/repos/%s/%s/pulls?%s", owner, repo, opt.query()),
+			nil, nil, &prs)
+		if err != nil {
+			return nil, err
+		}
+		if len(prs) == 0 {
+			return result, nil
+		}
+
+		for _, pr := range prs {
+			if pr == nil {
+				continue
+			}
+			if !opt.matches(pr.Poster, pr.Assignees, pr.Labels, pr.Milestone, pr.Title, pr.Body) {
+				continue
+			}
+			result = append(result, pr)
+			if opt.limitReached(len(result)) {
+				return result, nil
+			}
+		}
+	}
+}
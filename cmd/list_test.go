@@ -0,0 +1,79 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"net/url"
+	"testing"
+
+	"code.gitea.io/sdk/gitea"
+)
+
+func TestIssueQuery(t *testing.T) {
+	tests := []struct {
+		opt      listOptions
+		expected url.Values
+	}{
+		{
+			listOptions{ListIssueOption: gitea.ListIssueOption{Page: 1, State: "open"}},
+			url.Values{"page": {"1"}, "state": {"open"}},
+		},
+		{
+			listOptions{
+				ListIssueOption: gitea.ListIssueOption{Page: 3, State: "all"},
+				Labels:          []string{"bug", "help wanted"},
+				Milestone:       "v1.0",
+				Keyword:         "crash on start",
+				Author:          "alice",
+			},
+			url.Values{
+				"page": {"3"}, "state": {"all"}, "labels": {"bug,help wanted"},
+				"milestones": {"v1.0"}, "q": {"crash on start"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		q, err := url.ParseQuery(tt.opt.issueQuery())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Encode() != tt.expected.Encode() {
+			t.Errorf("issueQuery() = %s, expected %s", q.Encode(), tt.expected.Encode())
+		}
+	}
+}
+
+func TestListOptionsMatches(t *testing.T) {
+	alice, bob := &gitea.User{UserName: "alice"}, &gitea.User{UserName: "bob"}
+	labels := []*gitea.Label{{Name: "bug"}, {Name: "help wanted"}}
+	milestone := &gitea.Milestone{Title: "v1.0"}
+
+	tests := []struct {
+		name     string
+		opt      listOptions
+		expected bool
+	}{
+		{"no filters", listOptions{}, true},
+		{"author", listOptions{Author: "Alice"}, true},
+		{"other author", listOptions{Author: "bob"}, false},
+		{"assignee", listOptions{Assignee: "bob"}, true},
+		{"other assignee", listOptions{Assignee: "carol"}, false},
+		{"labels", listOptions{Labels: []string{"BUG", "help wanted"}}, true},
+		{"missing label", listOptions{Labels: []string{"bug", "feature"}}, false},
+		{"milestone", listOptions{Milestone: "V1.0"}, true},
+		{"other milestone", listOptions{Milestone: "v2.0"}, false},
+		{"keyword in title", listOptions{Keyword: "CRASH"}, true},
+		{"keyword in body", listOptions{Keyword: "stack trace"}, true},
+		{"missing keyword", listOptions{Keyword: "panic"}, false},
+	}
+
+	for _, tt := range tests {
+		actual := tt.opt.matches(alice, []*gitea.User{bob}, labels, milestone, "Crash on start", "See the stack trace")
+		if actual != tt.expected {
+			t.Errorf("%s: matches() = %v, expected %v", tt.name, actual, tt.expected)
+		}
+	}
+}
@@ -5,13 +5,15 @@
 package cmd
 
 import (
-	"crypto/tls"
 	"fmt"
-	"log"
 	"net/http"
-	"net/http/cookiejar"
+	"path/filepath"
+	"strconv"
 
 	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/credentials"
+	"code.gitea.io/tea/modules/output"
+	"code.gitea.io/tea/modules/utils"
 
 	"github.com/urfave/cli"
 )
@@ -25,15 +27,22 @@ var CmdLogin = cli.Command{
 	Subcommands: []cli.Command{
 		cmdLoginList,
 		cmdLoginAdd,
+		cmdLoginDefault,
+		cmdLoginEdit,
+		cmdLoginRename,
+		cmdLoginVerify,
+		cmdLoginTokens,
+		cmdLoginCredentialStore,
 	},
 }
 
 // CmdLogin represents to login a gitea server.
 var cmdLoginAdd = cli.Command{
-	Name:        "add",
-	Usage:       "Add a Login of a Gitea server",
-	Description: `Add a Login of a Gitea server`,
-	Flags: []cli.Flag{
+	Name:  "add",
+	Usage: "Add a Login of a Gitea server",
+	Description: `Add a Login of a Gitea server. Instead of giving an existing token, tea can
+create one when --user is given, the password is asked for if needed.`,
+	Flags: append([]cli.Flag{
 		cli.StringFlag{
 			Name:  "name, n",
 			Usage: "Name for the gitea login",
@@ -50,63 +59,179 @@ var cmdLoginAdd = cli.Command{
 			EnvVar: "GITEA_SERVER_TOKEN",
 			Usage:  "token for operating the Gitea login",
 		},
+		cli.StringFlag{
+			Name:  "ssh-host",
+			Usage: "SSH host used by the remotes of the server (default is the host of the URL)",
+		},
+		cli.IntFlag{
+			Name:  "ssh-port",
+			Usage: "SSH port used by the remotes of the server (default 22)",
+		},
+		cloneProtocolFlag,
 		cli.BoolFlag{
 			Name:  "insecure, i",
 			Usage: "insecure visit gitea server",
 		},
-	},
+	}, append(connectionFlags, basicAuthFlags...)...),
 	Action: runLoginAdd,
 }
 
-func runLoginAdd(ctx *cli.Context) error {
-	if !ctx.IsSet("url") {
-		log.Fatal("You have to input Gitea server URL")
-	}
+var cloneProtocolFlag = cli.StringFlag{
+	Name:  "clone-protocol",
+	Usage: "Protocol used by tea clone, https or ssh (default https)",
+}
 
-	if !ctx.IsSet("token") {
-		log.Fatal("No token found")
+// getCloneProtocol validates the clone-protocol flag
+func getCloneProtocol(ctx *cli.Context) (string, error) {
+	switch p := ctx.String("clone-protocol"); p {
+	case "", cloneHTTPS, cloneSSH:
+		return p, nil
+	default:
+		return "", usageError("unknown clone protocol %s, expected https or ssh", p)
 	}
+}
 
-	if !ctx.IsSet("name") {
-		log.Fatal("You have to set a name for the login")
-	}
+// connectionFlags configure how the server of a login is reached
+var connectionFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "ca-file",
+		Usage: "PEM file with the certificate authorities of the server",
+	},
+	cli.StringFlag{
+		Name:  "client-cert",
+		Usage: "PEM file with a TLS client certificate",
+	},
+	cli.StringFlag{
+		Name:  "client-key",
+		Usage: "PEM file with the key of the TLS client certificate",
+	},
+	cli.StringFlag{
+		Name:  "proxy",
+		Usage: "Proxy URL, 'direct' ignores HTTP_PROXY and HTTPS_PROXY",
+	},
+}
 
-	err := loadConfig(yamlConfigPath)
-	if err != nil {
-		log.Fatal("load config file failed", yamlConfigPath)
+// setConnectionFlags applies the connection flags which are set to the login
+func setConnectionFlags(ctx *cli.Context, login *Login) error {
+	for flag, field := range map[string]*string{
+		"ca-file":     &login.CAFile,
+		"client-cert": &login.ClientCert,
+		"client-key":  &login.ClientKey,
+	} {
+		if !ctx.IsSet(flag) {
+			continue
+		}
+		*field = ""
+		if path := ctx.String(flag); path != "" {
+			// the config is used from any directory
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return err
+			}
+			*field = abs
+		}
+	}
+	if ctx.IsSet("proxy") {
+		login.Proxy = ctx.String("proxy")
 	}
+	login.client = nil
+	return nil
+}
 
-	client := gitea.NewClient(ctx.String("url"), ctx.String("token"))
-	if ctx.Bool("insecure") {
-		cookieJar, _ := cookiejar.New(nil)
+func runLoginAdd(ctx *cli.Context) error {
+	interactive := isInteractive()
+	if !ctx.IsSet("url") && !interactive {
+		return usageError("You have to input Gitea server URL")
+	}
 
-		client.SetHTTPClient(&http.Client{
-			Jar: cookieJar,
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-			},
-		})
+	if !ctx.IsSet("token") && !ctx.IsSet("user") && !interactive {
+		return usageError("No token found, use --token or create one with --user")
 	}
-	u, err := client.GetMyUserInfo()
+
+	err := loadConfig(yamlConfigPath)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	fmt.Println("Login successful! Login name", u.UserName)
-
-	err = addLogin(Login{
+	login := Login{
 		Name:     ctx.String("name"),
 		URL:      ctx.String("url"),
 		Token:    ctx.String("token"),
+		SSHHost:  ctx.String("ssh-host"),
+		SSHPort:  ctx.Int("ssh-port"),
 		Insecure: ctx.Bool("insecure"),
-	})
+	}
+	if err = setConnectionFlags(ctx, &login); err != nil {
+		return err
+	}
+	if login.CloneProtocol, err = getCloneProtocol(ctx); err != nil {
+		return err
+	}
+	if !ctx.IsSet("url") {
+		if login.URL, err = utils.PromptRequired("Gitea server URL", login.URL); err != nil {
+			return err
+		}
+	}
+	if login.Name == "" {
+		if login.Name, err = loginNameFromURL(login.URL); err != nil {
+			return err
+		}
+		if interactive {
+			if login.Name, err = utils.PromptRequired("Name of the login", login.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	user := ctx.String("user")
+	if login.Token == "" && user == "" {
+		if login.Token, err = utils.PromptPassword("Token (empty to log in with username and password): "); err != nil {
+			return err
+		}
+		if login.Token == "" {
+			if user, err = utils.PromptRequired("Username", ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	// make sure there is a Gitea server before sending any password to it
+	if err = login.checkServer(); err != nil {
+		return err
+	}
+
+	if login.Token == "" {
+		auth, err := getBasicAuth(ctx, user)
+		if err != nil {
+			return err
+		}
+		token, err := login.mintAccessToken(auth)
+		if err != nil {
+			return err
+		}
+		login.Token = token.Token
+		login.TokenID = token.ID
+		fmt.Println("Created access token", token.Name)
+	}
+
+	if err = login.checkUser(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Login %s successful! Login name %s, Gitea %s\n", login.Name, login.User, login.ServerVersion)
+
+	stored, err := addLogin(login)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	if err = stored.storeToken(); err != nil {
+		return err
 	}
 
 	err = saveConfig(yamlConfigPath)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	return nil
@@ -118,18 +243,353 @@ var cmdLoginList = cli.Command{
 	Usage:       "List all Logins of Gitea servers",
 	Description: `List all Logins of Gitea servers`,
 	Action:      runLoginList,
+	Flags:       OutputFlags,
 }
 
 func runLoginList(ctx *cli.Context) error {
 	err := loadConfig(yamlConfigPath)
 	if err != nil {
-		log.Fatal("load config file failed", yamlConfigPath)
+		return err
 	}
 
-	fmt.Printf("Name\tURL\tSSHHost\n")
+	t := &output.Table{
+		Columns: []string{"name", "url", "user", "ssh_host", "active"},
+	}
+	logins := make([]Login, 0, len(config.Logins))
 	for _, l := range config.Logins {
-		fmt.Printf("%s\t%s\t%s\n", l.Name, l.URL, l.GetSSHHost())
+		t.AddRow(l.Name, l.URL, l.User, l.GetSSHHost(), strconv.FormatBool(l.Active))
+		// never leak the tokens into the output
+		l.Token = ""
+		logins = append(logins, l)
+	}
+	t.Items = logins
+
+	return printTable(ctx, t)
+}
+
+var cmdLoginCredentialStore = cli.Command{
+	Name:  "credential-store",
+	Usage: "Choose where the tokens of all logins are stored",
+	Description: `Choose where the tokens of all logins are stored and move the existing tokens there.
+
+   plain   keeps the tokens in cleartext in the tea config (default)
+   file    keeps the tokens in a file encrypted with a passphrase, which is
+           read from TEA_CREDENTIALS_PASSPHRASE or asked for
+   helper  uses a git credential helper, by default the ones configured for git`,
+	ArgsUsage: "<plain|file|helper>",
+	Action:    runLoginCredentialStore,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "helper",
+			Usage: "git credential helper for the helper store, e.g. osxkeychain or /path/to/helper",
+		},
+	},
+}
+
+func runLoginCredentialStore(ctx *cli.Context) error {
+	backend := ctx.Args().First()
+	switch backend {
+	case credentials.BackendPlain, credentials.BackendFile, credentials.BackendHelper:
+	default:
+		return usageError("unknown credential store %q, expected plain, file or helper", backend)
+	}
+
+	err := loadConfig(yamlConfigPath)
+	if err != nil {
+		return err
+	}
+
+	// tokens in the current store have to be read before switching
+	for i := range config.Logins {
+		if _, err = config.Logins[i].getToken(); err != nil {
+			return err
+		}
+	}
+
+	config.CredentialStore = backend
+	config.CredentialHelper = ctx.String("helper")
+	for i := range config.Logins {
+		if err = config.Logins[i].storeToken(); err != nil {
+			return err
+		}
+	}
+
+	if err = saveConfig(yamlConfigPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Tokens of %d logins are stored in the %s store\n", len(config.Logins), backend)
+	return nil
+}
+
+var cmdLoginDefault = cli.Command{
+	Name:  "default",
+	Usage: "Show or set the default login",
+	Description: `Show or set the login used when no login is given and none matches the
+remotes of the current repository. TEA_LOGIN overrides the default.`,
+	ArgsUsage: "[<login name>]",
+	Action:    runLoginDefault,
+}
+
+func runLoginDefault(ctx *cli.Context) error {
+	err := loadConfig(yamlConfigPath)
+	if err != nil {
+		return err
+	}
+
+	if !ctx.Args().Present() {
+		login, err := getActiveLogin()
+		if err != nil {
+			return err
+		}
+		fmt.Println(login.Name)
+		return nil
+	}
+
+	name := ctx.Args().First()
+	if getLoginByName(name) == nil {
+		return notFoundError("login %s does not exist", name)
+	}
+	for i := range config.Logins {
+		config.Logins[i].Active = config.Logins[i].Name == name
+	}
+
+	if err = saveConfig(yamlConfigPath); err != nil {
+		return err
+	}
+	fmt.Printf("Default login is %s\n", name)
+	return nil
+}
+
+var cmdLoginEdit = cli.Command{
+	Name:        "edit",
+	Usage:       "Change the settings of a login",
+	Description: `Change the settings of a login, the login is checked against the server before saving`,
+	ArgsUsage:   "<login name>",
+	Action:      runLoginEdit,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "url, u",
+			Usage: "Gitea server URL",
+		},
+		cli.StringFlag{
+			Name:  "token, t",
+			Usage: "token for operating the Gitea login",
+		},
+		cli.StringFlag{
+			Name:  "ssh-host",
+			Usage: "SSH host used by the remotes of the server",
+		},
+		cli.IntFlag{
+			Name:  "ssh-port",
+			Usage: "SSH port used by the remotes of the server (default 22)",
+		},
+		cloneProtocolFlag,
+		cli.BoolFlag{
+			Name:  "insecure, i",
+			Usage: "insecure visit gitea server, use --insecure=false to disable",
+		},
+	}, connectionFlags...),
+}
+
+func runLoginEdit(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return usageError("login name is required")
+	}
+
+	err := loadConfig(yamlConfigPath)
+	if err != nil {
+		return err
 	}
 
+	login := getLoginByName(ctx.Args().First())
+	if login == nil {
+		return notFoundError("login %s does not exist", ctx.Args().First())
+	}
+
+	// the credential stores key tokens by URL, so a new URL or token
+	// means the token has to be stored again
+	moveToken := ctx.IsSet("url") || ctx.IsSet("token")
+	if moveToken {
+		if _, err = login.getToken(); err != nil {
+			return err
+		}
+	}
+	// the login is only changed once the new settings are checked
+	old := *login
+	edited := *login
+	edited.client = nil
+	if moveToken {
+		edited.TokenRef = ""
+	}
+
+	if ctx.IsSet("url") {
+		edited.URL = ctx.String("url")
+	}
+	if ctx.IsSet("token") {
+		edited.Token = ctx.String("token")
+		edited.TokenID = 0
+	}
+	if ctx.IsSet("ssh-host") {
+		edited.SSHHost = ctx.String("ssh-host")
+	}
+	if ctx.IsSet("ssh-port") {
+		edited.SSHPort = ctx.Int("ssh-port")
+	}
+	if ctx.IsSet("insecure") {
+		edited.Insecure = ctx.Bool("insecure")
+	}
+	if ctx.IsSet("clone-protocol") {
+		if edited.CloneProtocol, err = getCloneProtocol(ctx); err != nil {
+			return err
+		}
+	}
+	if err = setConnectionFlags(ctx, &edited); err != nil {
+		return err
+	}
+
+	if err = edited.checkServer(); err != nil {
+		return err
+	}
+	if err = edited.checkUser(); err != nil {
+		return fmt.Errorf("checking login %s failed: %v", edited.Name, err)
+	}
+
+	if moveToken {
+		if err = edited.storeToken(); err != nil {
+			return err
+		}
+	}
+	*login = edited
+	if err = saveConfig(yamlConfigPath); err != nil {
+		return err
+	}
+
+	// the old secret is only deleted when the new one did not replace it
+	if moveToken && old.TokenRef != "" && (old.TokenRef != login.TokenRef || old.URL != login.URL) {
+		if err = old.deleteToken(); err != nil {
+			return fmt.Errorf("deleting the old token of login %s failed: %v", old.Name, err)
+		}
+	}
+	fmt.Printf("Login %s of %s saved\n", login.Name, login.User)
+	return nil
+}
+
+var cmdLoginRename = cli.Command{
+	Name:        "rename",
+	Usage:       "Rename a login",
+	Description: `Rename a login`,
+	ArgsUsage:   "<old name> <new name>",
+	Action:      runLoginRename,
+}
+
+func runLoginRename(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return usageError("old and new login name are required")
+	}
+	oldName, newName := ctx.Args().Get(0), ctx.Args().Get(1)
+
+	err := loadConfig(yamlConfigPath)
+	if err != nil {
+		return err
+	}
+
+	login := getLoginByName(oldName)
+	if login == nil {
+		return notFoundError("login %s does not exist", oldName)
+	}
+	if oldName == newName {
+		return nil
+	}
+	if getLoginByName(newName) != nil {
+		return conflictError("login %s already exists", newName)
+	}
+	login.Name = newName
+
+	return saveConfig(yamlConfigPath)
+}
+
+var cmdLoginVerify = cli.Command{
+	Name:        "verify",
+	Usage:       "Check that all logins still work",
+	Description: `Check every login against its server and report expired or revoked tokens`,
+	Action:      runLoginVerify,
+	Flags:       OutputFlags,
+}
+
+// loginStatus is the result of verifying a login
+type loginStatus struct {
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	User    string `json:"user"`
+	Version string `json:"version"`
+	Status  string `json:"status"`
+}
+
+func runLoginVerify(ctx *cli.Context) error {
+	err := loadConfig(yamlConfigPath)
+	if err != nil {
+		return err
+	}
+
+	t := &output.Table{
+		Columns: []string{"name", "url", "user", "version", "status"},
+	}
+	results := make([]loginStatus, 0, len(config.Logins))
+	var failed int
+	for i := range config.Logins {
+		l := &config.Logins[i]
+		status := "ok"
+		if err := l.checkServer(); err != nil {
+			status = err.Error()
+		} else if err := l.checkUser(); err != nil {
+			status = err.Error()
+			if isStatus(err, http.StatusUnauthorized) {
+				status = "token expired or revoked"
+			}
+		}
+		if status != "ok" {
+			failed++
+		}
+		t.AddRow(l.Name, l.URL, l.User, l.ServerVersion, status)
+		results = append(results, loginStatus{l.Name, l.URL, l.User, l.ServerVersion, status})
+	}
+	t.Items = results
+
+	if err = printTable(ctx, t); err != nil {
+		return err
+	}
+	// keep the refreshed users and versions
+	if err = saveConfig(yamlConfigPath); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d logins failed", failed, len(config.Logins))
+	}
+	return nil
+}
+
+// checkServer makes sure the URL of the login belongs to a Gitea server
+// and records its version
+func (l *Login) checkServer() error {
+	// the SDK requests a wrong URL for the version
+	var v gitea.ServerVersion
+	if err := l.basicLogin().getParsedResponse("GET", "/version", nil, nil, &v); err != nil {
+		return fmt.Errorf("%s does not look like a Gitea server: %v", l.URL, err)
+	}
+	if v.Version == "" {
+		return fmt.Errorf("%s does not look like a Gitea server", l.URL)
+	}
+	l.ServerVersion = v.Version
+	return nil
+}
+
+// checkUser verifies the token of the login and records its user
+func (l *Login) checkUser() error {
+	u := new(gitea.User)
+	if err := l.getParsedResponse("GET", "/user", nil, nil, u); err != nil {
+		return err
+	}
+	l.User = u.UserName
 	return nil
 }
@@ -0,0 +1,103 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoginNameFromURL(t *testing.T) {
+	saved := config.Logins
+	defer func() { config.Logins = saved }()
+	config.Logins = []Login{{Name: "gitea.com"}, {Name: "gitea.com-2"}, {Name: "gitea.io"}}
+
+	tests := []struct {
+		url      string
+		expected string
+		err      bool
+	}{
+		{"https://try.gitea.io", "try.gitea.io", false},
+		{"http://localhost:3000/gitea", "localhost", false},
+		{"https://gitea.io", "gitea.io-2", false},
+		{"https://gitea.com", "gitea.com-3", false},
+		{"gitea.com", "", true},
+		{"://gitea.com", "", true},
+	}
+	for _, tt := range tests {
+		name, err := loginNameFromURL(tt.url)
+		if tt.err {
+			if err == nil {
+				t.Errorf("loginNameFromURL(%q) = %q, expected an error", tt.url, name)
+			}
+			continue
+		}
+		if err != nil || name != tt.expected {
+			t.Errorf("loginNameFromURL(%q) = %q, %v, expected %q", tt.url, name, err, tt.expected)
+		}
+	}
+}
+
+func TestCheckServerAndUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		user    string
+		status  int
+		err     bool
+	}{
+		{"gitea", `{"version":"1.10.0"}`, `{"login":"alice"}`, http.StatusOK, false},
+		{"no version", `{}`, `{"login":"alice"}`, http.StatusOK, true},
+		{"not gitea", `<html></html>`, `{"login":"alice"}`, http.StatusOK, true},
+		{"revoked token", `{"version":"1.10.0"}`, `{"message":"token is required"}`, http.StatusUnauthorized, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var versionAuth string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/sub/api/v1/version":
+					versionAuth = r.Header.Get("Authorization")
+					fmt.Fprint(w, tt.version)
+				case "/sub/api/v1/user":
+					if r.Header.Get("Authorization") != "token abc" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					w.WriteHeader(tt.status)
+					fmt.Fprint(w, tt.user)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+
+			login := &Login{Name: "test", URL: srv.URL + "/sub/", Token: "abc"}
+			err := login.checkServer()
+			if err == nil {
+				err = login.checkUser()
+			}
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if login.ServerVersion != "1.10.0" || login.User != "alice" {
+				t.Errorf("recorded version %q and user %q, expected 1.10.0 and alice", login.ServerVersion, login.User)
+			}
+			// the version is public, the token is not sent to servers which may not be Gitea
+			if versionAuth != "" {
+				t.Errorf("the version was requested with the token")
+			}
+		})
+	}
+}
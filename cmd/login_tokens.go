@@ -0,0 +1,328 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/output"
+	"code.gitea.io/tea/modules/utils"
+
+	"github.com/urfave/cli"
+)
+
+// tokenNamePrefix is used for all access tokens created by tea
+const tokenNamePrefix = "tea-"
+
+// basicAuthFlags are used by the commands talking to the token endpoints
+// of the API, which only accept username and password
+var basicAuthFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "user",
+		Usage: "Username to authenticate with",
+	},
+	cli.StringFlag{
+		Name:   "password",
+		EnvVar: "GITEA_SERVER_PASSWORD",
+		Usage:  "Password of the user, asked for when not given",
+	},
+	cli.StringFlag{
+		Name:  "otp",
+		Usage: "Two-factor authentication passcode, asked for when required",
+	},
+}
+
+var cmdLoginTokens = cli.Command{
+	Name:  "tokens",
+	Usage: "List or prune the access tokens of a login's user",
+	Description: `List the access tokens of the user of a login. With --prune the tokens which
+were created by tea with this config and are not used by any login anymore are
+revoked, after listing them and asking for confirmation unless --yes is given.
+Tokens created on other machines are never pruned.`,
+	ArgsUsage: "[<login name>]",
+	Action:    runLoginTokens,
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "prune",
+			Usage: "Revoke unused tokens created by tea with this config",
+		},
+		cli.BoolFlag{
+			Name:  "yes, y",
+			Usage: "Prune without asking for confirmation",
+		},
+	}, append(basicAuthFlags, OutputFlags...)...),
+}
+
+// basicAuth holds the credentials for the token endpoints
+type basicAuth struct {
+	User     string
+	Password string
+	OTP      string
+}
+
+// getBasicAuth reads the credentials from the flags and asks for the
+// password when it is missing
+func getBasicAuth(ctx *cli.Context, user string) (*basicAuth, error) {
+	if ctx.String("user") != "" {
+		user = ctx.String("user")
+	}
+	if user == "" {
+		return nil, usageError("username is required, use --user")
+	}
+
+	auth := &basicAuth{
+		User:     user,
+		Password: ctx.String("password"),
+		OTP:      ctx.String("otp"),
+	}
+	if auth.Password == "" {
+		pass, err := utils.PromptPassword(fmt.Sprintf("Password for %s: ", user))
+		if err != nil {
+			return nil, err
+		}
+		auth.Password = pass
+	}
+	return auth, nil
+}
+
+func (a *basicAuth) header() http.Header {
+	h := http.Header{}
+	h.Set("Authorization", "Basic "+gitea.BasicAuthEncode(a.User, a.Password))
+	if a.OTP != "" {
+		h.Set("X-Gitea-OTP", a.OTP)
+	}
+	return h
+}
+
+// do calls fn with the authentication headers, when the server rejects
+// them and no passcode was given yet it asks for one and tries again
+func (a *basicAuth) do(fn func(http.Header) error) error {
+	err := fn(a.header())
+	if err == nil || a.OTP != "" || !isStatus(err, http.StatusUnauthorized) || !utils.IsTerminal(os.Stdin) {
+		return err
+	}
+
+	otp, perr := utils.Prompt("Two-factor authentication passcode (empty if not enabled): ")
+	if perr != nil || otp == "" {
+		return err
+	}
+	a.OTP = otp
+	return fn(a.header())
+}
+
+// basicLogin returns a copy of the login without its token, the token
+// endpoints of Gitea do not accept token authentication
+func (l *Login) basicLogin() *Login {
+	basic := *l
+	basic.Token, basic.TokenRef = "", ""
+	return &basic
+}
+
+func (l *Login) listAccessTokens(auth *basicAuth) ([]*gitea.AccessToken, error) {
+	tokens := make([]*gitea.AccessToken, 0, 10)
+	err := auth.do(func(h http.Header) error {
+		return l.basicLogin().getParsedResponse("GET",
+			fmt.Sprintf("/users/%s/tokens", url.PathEscape(auth.User)), h, nil, &tokens)
+	})
+	return tokens, err
+}
+
+func (l *Login) createAccessToken(auth *basicAuth, name string) (*gitea.AccessToken, error) {
+	body, err := json.Marshal(gitea.CreateAccessTokenOption{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	token := new(gitea.AccessToken)
+	err = auth.do(func(h http.Header) error {
+		h.Set("Content-Type", "application/json")
+		return l.basicLogin().getParsedResponse("POST",
+			fmt.Sprintf("/users/%s/tokens", url.PathEscape(auth.User)), h, bytes.NewReader(body), token)
+	})
+	return token, err
+}
+
+// deleteAccessToken revokes a token, the SDK builds a wrong URL for it
+func (l *Login) deleteAccessToken(auth *basicAuth, id int64) error {
+	return auth.do(func(h http.Header) error {
+		_, err := l.basicLogin().getResponse("DELETE",
+			fmt.Sprintf("/users/%s/tokens/%d", url.PathEscape(auth.User), id), h, nil)
+		return err
+	})
+}
+
+// mintAccessToken creates a new token named after the host of the login
+// and the current date
+func (l *Login) mintAccessToken(auth *basicAuth) (*gitea.AccessToken, error) {
+	host := l.URL
+	if u, err := url.Parse(l.URL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	name := tokenNamePrefix + host + "-" + time.Now().Format("20060102")
+
+	// token names have to be unique per user
+	tokens, err := l.listAccessTokens(auth)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		taken[t.Name] = true
+	}
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = name + "-" + strconv.Itoa(i)
+	}
+
+	token, err := l.createAccessToken(auth, unique)
+	if err != nil {
+		return nil, err
+	}
+	config.CreatedTokens = append(config.CreatedTokens, createdToken{URL: l.URL, User: auth.User, ID: token.ID})
+	return token, nil
+}
+
+// ownsCreatedToken reports whether the created token is one of user on
+// the server of l
+func (l *Login) ownsCreatedToken(t createdToken, user string) bool {
+	return strings.EqualFold(t.User, user) && strings.TrimSuffix(t.URL, "/") == strings.TrimSuffix(l.URL, "/")
+}
+
+// createdTokenIndex returns the index of the record of a token created
+// with this config on the server and user of l, or -1
+func (l *Login) createdTokenIndex(id int64, user string) int {
+	for i, t := range config.CreatedTokens {
+		if t.ID == id && l.ownsCreatedToken(t, user) {
+			return i
+		}
+	}
+	return -1
+}
+
+// forgetCreatedToken removes the record of a token created with this
+// config on the server and user of l, once it was revoked
+func (l *Login) forgetCreatedToken(id int64, user string) {
+	if idx := l.createdTokenIndex(id, user); idx >= 0 {
+		config.CreatedTokens = append(config.CreatedTokens[:idx], config.CreatedTokens[idx+1:]...)
+	}
+}
+
+// tokenUsedBy returns the names of the logins using the token id on
+// the server and user of l
+func (l *Login) tokenUsedBy(id int64, user string) []string {
+	var names []string
+	for _, other := range config.Logins {
+		if other.TokenID == id && strings.EqualFold(other.User, user) &&
+			strings.TrimSuffix(other.URL, "/") == strings.TrimSuffix(l.URL, "/") {
+			names = append(names, other.Name)
+		}
+	}
+	return names
+}
+
+func runLoginTokens(ctx *cli.Context) error {
+	err := loadConfig(yamlConfigPath)
+	if err != nil {
+		return err
+	}
+
+	var login *Login
+	if ctx.Args().Present() {
+		if login = getLoginByName(ctx.Args().First()); login == nil {
+			return notFoundError("login %s does not exist", ctx.Args().First())
+		}
+	} else if login, err = getActiveLogin(); err != nil {
+		return err
+	}
+
+	auth, err := getBasicAuth(ctx, login.User)
+	if err != nil {
+		return err
+	}
+	tokens, err := login.listAccessTokens(auth)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("prune") {
+		return pruneAccessTokens(ctx, login, auth, tokens)
+	}
+
+	t := &output.Table{
+		Columns: []string{"id", "name", "last_eight", "used_by"},
+		Items:   tokens,
+	}
+	for _, token := range tokens {
+		t.AddRow(strconv.FormatInt(token.ID, 10), token.Name, token.TokenLastEight,
+			strings.Join(login.tokenUsedBy(token.ID, auth.User), ", "))
+	}
+	return printTable(ctx, t)
+}
+
+// pruneAccessTokens revokes the tokens created with this config which no
+// login uses anymore
+func pruneAccessTokens(ctx *cli.Context, login *Login, auth *basicAuth, tokens []*gitea.AccessToken) error {
+	var unused []*gitea.AccessToken
+	exists := make(map[int]bool)
+	for _, t := range tokens {
+		idx := login.createdTokenIndex(t.ID, auth.User)
+		if idx < 0 {
+			continue
+		}
+		exists[idx] = true
+		if len(login.tokenUsedBy(t.ID, auth.User)) == 0 {
+			unused = append(unused, t)
+		}
+	}
+
+	// forget the tokens which were revoked elsewhere
+	kept := config.CreatedTokens[:0]
+	for i, t := range config.CreatedTokens {
+		if exists[i] || !login.ownsCreatedToken(t, auth.User) {
+			kept = append(kept, t)
+		}
+	}
+	config.CreatedTokens = kept
+
+	if len(unused) == 0 {
+		fmt.Println("No unused tokens created by tea")
+		return saveConfig(yamlConfigPath)
+	}
+
+	fmt.Println("Unused tokens created by tea:")
+	for _, t := range unused {
+		fmt.Printf("  %s (id %d)\n", t.Name, t.ID)
+	}
+	if !ctx.Bool("yes") {
+		if !isInteractive() {
+			return usageError("use --yes to revoke the tokens without confirmation")
+		}
+		answer, err := utils.Prompt(fmt.Sprintf("Revoke %d tokens? [y/N]: ", len(unused)))
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(answer, "y") && !strings.EqualFold(answer, "yes") {
+			fmt.Println("No tokens were revoked")
+			return saveConfig(yamlConfigPath)
+		}
+	}
+
+	for _, t := range unused {
+		if err := login.deleteAccessToken(auth, t.ID); err != nil {
+			return err
+		}
+		login.forgetCreatedToken(t.ID, auth.User)
+		fmt.Printf("Revoked token %s\n", t.Name)
+	}
+	return saveConfig(yamlConfigPath)
+}
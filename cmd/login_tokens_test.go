@@ -0,0 +1,64 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenUsedBy(t *testing.T) {
+	saved := config.Logins
+	defer func() { config.Logins = saved }()
+	config.Logins = []Login{
+		{Name: "a", URL: "https://gitea.com", User: "alice", TokenID: 7},
+		{Name: "b", URL: "https://gitea.com/", User: "Alice", TokenID: 7},
+		{Name: "c", URL: "https://gitea.com", User: "alice", TokenID: 8},
+		{Name: "d", URL: "https://gitea.io", User: "alice", TokenID: 7},
+		{Name: "e", URL: "https://gitea.com", User: "bob", TokenID: 7},
+	}
+	login := &config.Logins[0]
+
+	tests := []struct {
+		id       int64
+		user     string
+		expected []string
+	}{
+		{7, "alice", []string{"a", "b"}},
+		{8, "alice", []string{"c"}},
+		{7, "bob", []string{"e"}},
+		{9, "alice", nil},
+	}
+	for _, tt := range tests {
+		if actual := login.tokenUsedBy(tt.id, tt.user); !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("tokenUsedBy(%d, %s) = %v, expected %v", tt.id, tt.user, actual, tt.expected)
+		}
+	}
+}
+
+func TestForgetCreatedToken(t *testing.T) {
+	saved := config.CreatedTokens
+	defer func() { config.CreatedTokens = saved }()
+	config.CreatedTokens = []createdToken{
+		{URL: "https://gitea.com", User: "alice", ID: 7},
+		{URL: "https://gitea.io", User: "alice", ID: 8},
+		{URL: "https://gitea.com/", User: "alice", ID: 8},
+		{URL: "https://gitea.com", User: "bob", ID: 9},
+	}
+	login := &Login{URL: "https://gitea.com"}
+
+	login.forgetCreatedToken(8, "Alice")
+	login.forgetCreatedToken(9, "alice")
+	login.forgetCreatedToken(10, "alice")
+
+	expected := []createdToken{
+		{URL: "https://gitea.com", User: "alice", ID: 7},
+		{URL: "https://gitea.io", User: "alice", ID: 8},
+		{URL: "https://gitea.com", User: "bob", ID: 9},
+	}
+	if !reflect.DeepEqual(config.CreatedTokens, expected) {
+		t.Errorf("CreatedTokens = %+v, expected %+v", config.CreatedTokens, expected)
+	}
+}
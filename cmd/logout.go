@@ -5,9 +5,8 @@
 package cmd
 
 import (
-	"errors"
-	"log"
-	"os"
+	"fmt"
+	"strings"
 
 	"github.com/urfave/cli"
 )
@@ -16,29 +15,34 @@ import (
 var CmdLogout = cli.Command{
 	Name:        "logout",
 	Usage:       "Log out from a Gitea server",
-	Description: `Log out from a Gitea server`,
+	Description: `Log out from a Gitea server. Tokens created by tea can be revoked with --revoke.`,
+	ArgsUsage:   "<login name>",
 	Action:      runLogout,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		cli.StringFlag{
 			Name:  "name, n",
 			Usage: "name wants to log out",
 		},
-	},
+		cli.BoolFlag{
+			Name:  "revoke",
+			Usage: "Revoke the access token on the server, unless another login uses it",
+		},
+	}, basicAuthFlags...),
 }
 
 func runLogout(ctx *cli.Context) error {
 	var name string
-	if len(os.Args) == 3 {
-		name = os.Args[2]
+	if ctx.Args().Present() {
+		name = ctx.Args().First()
 	} else if ctx.IsSet("name") {
 		name = ctx.String("name")
 	} else {
-		return errors.New("need log out server name")
+		return usageError("need log out server name")
 	}
 
 	err := loadConfig(yamlConfigPath)
 	if err != nil {
-		log.Fatal("load config file failed", yamlConfigPath)
+		return err
 	}
 
 	var idx = -1
@@ -49,12 +53,49 @@ func runLogout(ctx *cli.Context) error {
 		}
 	}
 	if idx > -1 {
+		if ctx.Bool("revoke") {
+			if err = revokeLoginToken(ctx, &config.Logins[idx]); err != nil {
+				return err
+			}
+		}
+		if err = config.Logins[idx].deleteToken(); err != nil {
+			return err
+		}
 		config.Logins = append(config.Logins[:idx], config.Logins[idx+1:]...)
 		err = saveConfig(yamlConfigPath)
 		if err != nil {
-			log.Fatal("save config file failed", yamlConfigPath)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revokeLoginToken deletes the token of the login on the server
+func revokeLoginToken(ctx *cli.Context, login *Login) error {
+	if login.TokenID == 0 {
+		return fmt.Errorf("the token of login %s was not created by tea, revoke it in the web interface", login.Name)
+	}
+	// revoking a shared token would break the other logins
+	var others []string
+	for _, name := range login.tokenUsedBy(login.TokenID, login.User) {
+		if name != login.Name {
+			others = append(others, name)
 		}
 	}
+	if len(others) > 0 {
+		return conflictError("the token of login %s is used by %s as well, log out without --revoke",
+			login.Name, strings.Join(others, ", "))
+	}
 
+	auth, err := getBasicAuth(ctx, login.User)
+	if err != nil {
+		return err
+	}
+	if err = login.deleteAccessToken(auth, login.TokenID); err != nil {
+		return err
+	}
+	login.forgetCreatedToken(login.TokenID, auth.User)
+	fmt.Printf("Revoked the token of login %s\n", login.Name)
 	return nil
 }
@@ -0,0 +1,411 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/output"
+
+	"github.com/urfave/cli"
+)
+
+// CmdMilestones represents to operate the milestones of a repository
+var CmdMilestones = cli.Command{
+	Name:        "milestones",
+	Usage:       "Manage the milestones of the repository",
+	Description: `List, create, edit, close, reopen and delete milestones and show their progress`,
+	Action:      runMilestonesList,
+	Subcommands: []cli.Command{
+		CmdMilestonesList,
+		CmdMilestonesShow,
+		CmdMilestonesCreate,
+		CmdMilestonesEdit,
+		CmdMilestonesClose,
+		CmdMilestonesReopen,
+		CmdMilestonesDelete,
+	},
+	Flags: CmdMilestonesList.Flags,
+}
+
+// listMilestones fetches the milestones of a repository in a state, the
+// SDK only lists the open ones
+func listMilestones(login *Login, owner, repo, state string) ([]*gitea.Milestone, error) {
+	milestones := make([]*gitea.Milestone, 0, 10)
+	err := login.getParsedResponse("GET",
+		fmt.Sprintf("/repos/%s/%s/milestones?state=%s", owner, repo, url.QueryEscape(state)),
+		nil, nil, &milestones)
+	if err != nil || state == "all" {
+		return milestones, err
+	}
+
+	// older servers ignore the state
+	filtered := milestones[:0]
+	for _, m := range milestones {
+		if string(m.State) == state {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered, nil
+}
+
+// getMilestone looks a milestone up by its title, ignoring the case
+func getMilestone(login *Login, owner, repo, title string) (*gitea.Milestone, error) {
+	if title == "" {
+		return nil, usageError("milestone title is required")
+	}
+	milestones, err := listMilestones(login, owner, repo, "all")
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range milestones {
+		if strings.EqualFold(m.Title, title) {
+			return m, nil
+		}
+	}
+	return nil, notFoundError("milestone %s does not exist", title)
+}
+
+// milestoneDue returns the due date of a milestone, Gitea stores a date
+// in the year 9999 for milestones without one
+func milestoneDue(m *gitea.Milestone) *time.Time {
+	if m.Deadline == nil || m.Deadline.Year() >= 9999 {
+		return nil
+	}
+	return m.Deadline
+}
+
+// milestonePercent returns the share of closed issues of a milestone
+func milestonePercent(m *gitea.Milestone) int {
+	total := m.OpenIssues + m.ClosedIssues
+	if total == 0 {
+		return 0
+	}
+	return m.ClosedIssues * 100 / total
+}
+
+// daysUntil returns the number of calendar days from now to t, negative
+// when t is in the past
+func daysUntil(t, now time.Time) int {
+	// the days are counted in UTC, local days can have 23 or 25 hours
+	y, m, d := t.In(now.Location()).Date()
+	due := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	y, m, d = now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return int(due.Sub(today).Hours() / 24)
+}
+
+// formatDue describes the due date of an open milestone relative to today
+func formatDue(m *gitea.Milestone, now time.Time) string {
+	due := milestoneDue(m)
+	if due == nil {
+		return "no due date"
+	}
+	date := due.In(now.Location()).Format("2006-01-02")
+	if m.State == gitea.StateClosed {
+		return date
+	}
+	switch days := daysUntil(*due, now); {
+	case days > 1:
+		return fmt.Sprintf("%s, in %d days", date, days)
+	case days == 1:
+		return fmt.Sprintf("%s, tomorrow", date)
+	case days == 0:
+		return fmt.Sprintf("%s, today", date)
+	case days == -1:
+		return fmt.Sprintf("%s, overdue by 1 day", date)
+	default:
+		return fmt.Sprintf("%s, overdue by %d days", date, -days)
+	}
+}
+
+func milestonesTable(milestones []*gitea.Milestone) *output.Table {
+	t := &output.Table{
+		Columns: []string{"title", "state", "open", "closed", "done", "due"},
+		Items:   milestones,
+	}
+	now := time.Now()
+	for _, m := range milestones {
+		t.AddRow(m.Title, string(m.State), strconv.Itoa(m.OpenIssues), strconv.Itoa(m.ClosedIssues),
+			fmt.Sprintf("%d%%", milestonePercent(m)), formatDue(m, now))
+	}
+	return t
+}
+
+// CmdMilestonesList represents a sub command of milestones to list them
+var CmdMilestonesList = cli.Command{
+	Name:        "ls",
+	Usage:       "List the milestones of the repository",
+	Description: `List the milestones of the repository with their progress`,
+	Action:      runMilestonesList,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "state, s",
+			Value: "open",
+			Usage: "Filter by state: open, closed or all",
+		},
+	}, append(OutputFlags, LoginRepoFlags...)...),
+}
+
+func runMilestonesList(ctx *cli.Context) error {
+	state := strings.ToLower(ctx.String("state"))
+	switch state {
+	case "":
+		state = string(gitea.StateOpen)
+	case string(gitea.StateOpen), string(gitea.StateClosed), "all":
+	default:
+		return usageError("unknown state %s, expected open, closed or all", state)
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	milestones, err := listMilestones(login, owner, repo, state)
+	if err != nil {
+		return err
+	}
+
+	if len(milestones) == 0 && !isStructuredOutput(ctx) {
+		fmt.Println("No milestones")
+		return nil
+	}
+	return printTable(ctx, milestonesTable(milestones))
+}
+
+// milestoneReport is the progress of a milestone as shown by milestones show
+type milestoneReport struct {
+	*gitea.Milestone
+	PercentDone int            `json:"percent_done"`
+	DaysLeft    *int           `json:"days_left,omitempty"`
+	Remaining   []*gitea.Issue `json:"remaining_issues"`
+}
+
+// CmdMilestonesShow represents a sub command of milestones to show the
+// progress of one
+var CmdMilestonesShow = cli.Command{
+	Name:        "show",
+	Usage:       "Show the progress of a milestone",
+	Description: `Show the progress of a milestone, its due date and the issues which are still open`,
+	ArgsUsage:   "<title>",
+	Action:      runMilestonesShow,
+	Flags:       append(OutputFlags, LoginRepoFlags...),
+}
+
+func runMilestonesShow(ctx *cli.Context) error {
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	m, err := getMilestone(login, owner, repo, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	opt := listOptions{
+		ListIssueOption: gitea.ListIssueOption{Page: 1, State: string(gitea.StateOpen)},
+		Milestone:       m.Title,
+	}
+	remaining, err := listRepoIssues(login, owner, repo, opt)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	report := milestoneReport{
+		Milestone:   m,
+		PercentDone: milestonePercent(m),
+		Remaining:   remaining,
+	}
+	if due := milestoneDue(m); due != nil && m.State == gitea.StateOpen {
+		days := daysUntil(*due, now)
+		report.DaysLeft = &days
+	}
+	if isStructuredOutput(ctx) {
+		return printItem(ctx, report, milestonesTable([]*gitea.Milestone{m}))
+	}
+
+	fmt.Printf("%s (%s)\n", m.Title, m.State)
+	if m.Description != "" {
+		fmt.Printf("%s\n", m.Description)
+	}
+	fmt.Printf("\nProgress: %d of %d issues closed, %d%% done\n",
+		m.ClosedIssues, m.OpenIssues+m.ClosedIssues, report.PercentDone)
+	if m.State == gitea.StateClosed && m.Closed != nil {
+		fmt.Printf("Closed:   %s\n", formatTime(*m.Closed))
+	}
+	fmt.Printf("Due:      %s\n", formatDue(m, now))
+
+	if len(remaining) == 0 {
+		return nil
+	}
+	fmt.Println("\nRemaining issues:")
+	return printTable(ctx, issuesTable(remaining))
+}
+
+// CmdMilestonesCreate represents a sub command of milestones to create one
+var CmdMilestonesCreate = cli.Command{
+	Name:        "create",
+	Usage:       "Create a milestone",
+	Description: `Create a milestone`,
+	ArgsUsage:   "<title>",
+	Action:      runMilestonesCreate,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "description, d",
+			Usage: "Description of the milestone",
+		},
+		cli.StringFlag{
+			Name:  "deadline, D",
+			Usage: "Due date of the milestone, YYYY-MM-DD",
+		},
+	}, LoginRepoFlags...),
+}
+
+func runMilestonesCreate(ctx *cli.Context) error {
+	title := ctx.Args().First()
+	if title == "" {
+		return usageError("milestone title is required")
+	}
+	deadline, err := parseDeadline(ctx.String("deadline"))
+	if err != nil {
+		return err
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	m, err := login.Client().CreateMilestone(owner, repo, gitea.CreateMilestoneOption{
+		Title:       title,
+		Description: ctx.String("description"),
+		Deadline:    deadline,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created milestone %s\n", m.Title)
+	return nil
+}
+
+// CmdMilestonesEdit represents a sub command of milestones to edit one
+var CmdMilestonesEdit = cli.Command{
+	Name:        "edit",
+	Usage:       "Change a milestone",
+	Description: `Change the title, description or due date of a milestone`,
+	ArgsUsage:   "<title>",
+	Action:      runMilestonesEdit,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "title, t",
+			Usage: "New title of the milestone",
+		},
+		cli.StringFlag{
+			Name:  "description, d",
+			Usage: "Description of the milestone",
+		},
+		cli.StringFlag{
+			Name:  "deadline, D",
+			Usage: "Due date of the milestone, YYYY-MM-DD",
+		},
+	}, LoginRepoFlags...),
+}
+
+func runMilestonesEdit(ctx *cli.Context) error {
+	opt := gitea.EditMilestoneOption{Title: ctx.String("title")}
+	if ctx.IsSet("description") {
+		description := ctx.String("description")
+		opt.Description = &description
+	}
+	var err error
+	if opt.Deadline, err = parseDeadline(ctx.String("deadline")); err != nil {
+		return err
+	}
+
+	return editMilestone(ctx, opt, "Updated")
+}
+
+// CmdMilestonesClose represents a sub command of milestones to close one
+var CmdMilestonesClose = cli.Command{
+	Name:        "close",
+	Usage:       "Close a milestone",
+	Description: `Close a milestone`,
+	ArgsUsage:   "<title>",
+	Action:      runMilestonesClose,
+	Flags:       LoginRepoFlags,
+}
+
+func runMilestonesClose(ctx *cli.Context) error {
+	state := string(gitea.StateClosed)
+	return editMilestone(ctx, gitea.EditMilestoneOption{State: &state}, "Closed")
+}
+
+// CmdMilestonesReopen represents a sub command of milestones to reopen one
+var CmdMilestonesReopen = cli.Command{
+	Name:        "reopen",
+	Usage:       "Reopen a closed milestone",
+	Description: `Reopen a closed milestone`,
+	ArgsUsage:   "<title>",
+	Action:      runMilestonesReopen,
+	Flags:       LoginRepoFlags,
+}
+
+func runMilestonesReopen(ctx *cli.Context) error {
+	state := string(gitea.StateOpen)
+	return editMilestone(ctx, gitea.EditMilestoneOption{State: &state}, "Reopened")
+}
+
+// editMilestone applies opt to the milestone named by the first argument
+func editMilestone(ctx *cli.Context, opt gitea.EditMilestoneOption, done string) error {
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	m, err := getMilestone(login, owner, repo, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	if m, err = login.Client().EditMilestone(owner, repo, m.ID, opt); err != nil {
+		return err
+	}
+	fmt.Printf("%s milestone %s\n", done, m.Title)
+	return nil
+}
+
+// CmdMilestonesDelete represents a sub command of milestones to delete one
+var CmdMilestonesDelete = cli.Command{
+	Name:        "delete",
+	Usage:       "Delete a milestone",
+	Description: `Delete a milestone, its issues are kept`,
+	ArgsUsage:   "<title>",
+	Action:      runMilestonesDelete,
+	Flags:       LoginRepoFlags,
+}
+
+func runMilestonesDelete(ctx *cli.Context) error {
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	m, err := getMilestone(login, owner, repo, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	if err = login.Client().DeleteMilestone(owner, repo, m.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted milestone %s\n", m.Title)
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+)
+
+func TestMilestonePercent(t *testing.T) {
+	tests := []struct {
+		open, closed int
+		expected     int
+	}{
+		{0, 0, 0},
+		{3, 0, 0},
+		{0, 3, 100},
+		{1, 1, 50},
+		{2, 1, 33},
+		{1, 2, 66},
+	}
+
+	for _, tt := range tests {
+		m := &gitea.Milestone{OpenIssues: tt.open, ClosedIssues: tt.closed}
+		if actual := milestonePercent(m); actual != tt.expected {
+			t.Errorf("milestonePercent(%d open, %d closed) = %d, expected %d", tt.open, tt.closed, actual, tt.expected)
+		}
+	}
+}
+
+func TestDaysUntil(t *testing.T) {
+	cet := time.FixedZone("CET", 3600)
+	now := time.Date(2019, 10, 15, 23, 30, 0, 0, cet)
+
+	tests := []struct {
+		name     string
+		due      time.Time
+		expected int
+	}{
+		{"same day", time.Date(2019, 10, 15, 0, 0, 0, 0, cet), 0},
+		{"tomorrow", time.Date(2019, 10, 16, 0, 0, 0, 0, cet), 1},
+		{"yesterday", time.Date(2019, 10, 14, 23, 59, 0, 0, cet), -1},
+		// the due date is taken in the time zone of now
+		{"UTC due date", time.Date(2019, 10, 15, 23, 0, 0, 0, time.UTC), 1},
+		{"next year", time.Date(2020, 10, 15, 12, 0, 0, 0, cet), 366},
+	}
+
+	for _, tt := range tests {
+		if actual := daysUntil(tt.due, now); actual != tt.expected {
+			t.Errorf("%s: daysUntil() = %d, expected %d", tt.name, actual, tt.expected)
+		}
+	}
+
+	// a day with a daylight saving time change has 23 hours
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data is not available: %v", err)
+	}
+	now = time.Date(2019, 3, 30, 12, 0, 0, 0, berlin)
+	if actual := daysUntil(time.Date(2019, 3, 31, 0, 0, 0, 0, berlin), now); actual != 1 {
+		t.Errorf("daysUntil() over a daylight saving time change = %d, expected 1", actual)
+	}
+	if actual := daysUntil(time.Date(2019, 4, 1, 0, 0, 0, 0, berlin), now); actual != 2 {
+		t.Errorf("daysUntil() after a daylight saving time change = %d, expected 2", actual)
+	}
+}
+
+func TestFormatDue(t *testing.T) {
+	now := time.Date(2019, 10, 15, 12, 0, 0, 0, time.UTC)
+	date := func(day int) *time.Time {
+		d := time.Date(2019, 10, day, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	noDueDate := time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+
+	tests := []struct {
+		deadline *time.Time
+		state    gitea.StateType
+		expected string
+	}{
+		{nil, gitea.StateOpen, "no due date"},
+		{&noDueDate, gitea.StateOpen, "no due date"},
+		{date(25), gitea.StateOpen, "2019-10-25, in 10 days"},
+		{date(16), gitea.StateOpen, "2019-10-16, tomorrow"},
+		{date(15), gitea.StateOpen, "2019-10-15, today"},
+		{date(14), gitea.StateOpen, "2019-10-14, overdue by 1 day"},
+		{date(1), gitea.StateOpen, "2019-10-01, overdue by 14 days"},
+		{date(1), gitea.StateClosed, "2019-10-01"},
+	}
+
+	for _, tt := range tests {
+		m := &gitea.Milestone{Deadline: tt.deadline, State: tt.state}
+		if actual := formatDue(m, now); actual != tt.expected {
+			t.Errorf("formatDue(%v, %s) = %q, expected %q", tt.deadline, tt.state, actual, tt.expected)
+		}
+	}
+}
+
+func TestResolveMilestoneID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repos/owner/repo/milestones" {
+			http.NotFound(w, r)
+			return
+		}
+		// closed milestones are only listed with state=all
+		milestones := []*gitea.Milestone{{ID: 1, Title: "v1.0", State: gitea.StateOpen}}
+		if r.URL.Query().Get("state") == "all" {
+			milestones = append(milestones, &gitea.Milestone{ID: 2, Title: "v0.9", State: gitea.StateClosed})
+		}
+		json.NewEncoder(w).Encode(milestones)
+	}))
+	defer srv.Close()
+	login := &Login{Name: "test", URL: srv.URL}
+
+	tests := []struct {
+		name     string
+		expected int64
+		notFound bool
+	}{
+		{"", 0, false},
+		{"v1.0", 1, false},
+		{"V0.9", 2, false},
+		{"v2.0", 0, true},
+	}
+	for _, tt := range tests {
+		id, err := resolveMilestoneID(login, "owner", "repo", tt.name)
+		if tt.notFound {
+			if ce, ok := err.(*CommandError); !ok || ce.Code != ExitNotFound {
+				t.Errorf("resolveMilestoneID(%q) = %d, %v, expected a not found error", tt.name, id, err)
+			}
+			continue
+		}
+		if err != nil || id != tt.expected {
+			t.Errorf("resolveMilestoneID(%q) = %d, %v, expected %d", tt.name, id, err, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/output"
+
+	"github.com/urfave/cli"
+)
+
+// CmdOrgs represents to operate organizations
+var CmdOrgs = cli.Command{
+	Name:        "orgs",
+	Usage:       "Operate with organizations",
+	Description: `List, show, create and edit organizations and list their members`,
+	Action:      runOrgsList,
+	Subcommands: []cli.Command{
+		CmdOrgsList,
+		CmdOrgsShow,
+		CmdOrgsCreate,
+		CmdOrgsEdit,
+		CmdOrgsMembers,
+	},
+	Flags: CmdOrgsList.Flags,
+}
+
+// organization is an organization with its visibility, which servers send
+// as a name while the SDK expects a number
+type organization struct {
+	ID          int64         `json:"id,omitempty"`
+	UserName    string        `json:"username"`
+	FullName    string        `json:"full_name"`
+	AvatarURL   string        `json:"avatar_url,omitempty"`
+	Description string        `json:"description"`
+	Website     string        `json:"website"`
+	Location    string        `json:"location"`
+	Visibility  orgVisibility `json:"visibility,omitempty"`
+}
+
+// orgVisibility is public, limited or private
+type orgVisibility string
+
+// UnmarshalJSON accepts the names as well as the numbers older servers send
+func (v *orgVisibility) UnmarshalJSON(data []byte) error {
+	var n gitea.VisibleType
+	if err := json.Unmarshal(data, &n); err == nil {
+		for name, vt := range gitea.VisibilityModes {
+			if vt == n {
+				*v = orgVisibility(name)
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown visibility %d", n)
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	*v = orgVisibility(name)
+	return nil
+}
+
+func parseOrgVisibility(val string) (orgVisibility, error) {
+	if _, ok := gitea.VisibilityModes[val]; !ok {
+		return "", usageError("unknown visibility %s, expected public, limited or private", val)
+	}
+	return orgVisibility(val), nil
+}
+
+func getOrg(login *Login, name string) (*organization, error) {
+	org := new(organization)
+	return org, login.getParsedResponse("GET", fmt.Sprintf("/orgs/%s", name), nil, nil, org)
+}
+
+// CmdOrgsList represents a sub command of orgs to list organizations
+var CmdOrgsList = cli.Command{
+	Name:        "ls",
+	Usage:       "List organizations",
+	Description: `List the organizations of the login's user or of another user`,
+	Action:      runOrgsList,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "user",
+			Usage: "List the organizations of a user",
+		},
+		LoginFlag,
+	}, OutputFlags...),
+}
+
+func runOrgsList(ctx *cli.Context) error {
+	login, err := initLoginCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	path := "/user/orgs"
+	if ctx.String("user") != "" {
+		path = fmt.Sprintf("/users/%s/orgs", ctx.String("user"))
+	}
+	orgs := make([]*organization, 0, 10)
+	if err = login.getParsedResponse("GET", path, nil, nil, &orgs); err != nil {
+		return err
+	}
+
+	if len(orgs) == 0 && !isStructuredOutput(ctx) {
+		fmt.Println("No organizations")
+		return nil
+	}
+	return printTable(ctx, orgsTable(orgs))
+}
+
+func orgsTable(orgs []*organization) *output.Table {
+	t := &output.Table{
+		Columns: []string{"name", "full name", "visibility", "website", "description"},
+		Items:   orgs,
+	}
+	for _, o := range orgs {
+		t.AddRow(o.UserName, o.FullName, string(o.Visibility), o.Website, o.Description)
+	}
+	return t
+}
+
+// CmdOrgsShow represents a sub command of orgs to show an organization
+var CmdOrgsShow = cli.Command{
+	Name:        "show",
+	Usage:       "Show the details of an organization",
+	Description: `Show the details and teams of an organization`,
+	ArgsUsage:   "<organization>",
+	Action:      runOrgsShow,
+	Flags:       append([]cli.Flag{LoginFlag}, OutputFlags...),
+}
+
+func runOrgsShow(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return usageError("organization name is required")
+	}
+	login, err := initLoginCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	org, err := getOrg(login, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	if isStructuredOutput(ctx) {
+		return printItem(ctx, org, orgsTable([]*organization{org}))
+	}
+
+	fmt.Printf("%s (%s)\n", org.UserName, org.Visibility)
+	if org.FullName != "" {
+		fmt.Printf("%s\n", org.FullName)
+	}
+	if org.Description != "" {
+		fmt.Printf("%s\n", org.Description)
+	}
+	fmt.Println()
+	if org.Website != "" {
+		fmt.Printf("Website:  %s\n", org.Website)
+	}
+	if org.Location != "" {
+		fmt.Printf("Location: %s\n", org.Location)
+	}
+
+	// only members can see the teams
+	teams, err := listTeams(login, org.UserName)
+	if err == nil && len(teams) > 0 {
+		fmt.Println("Teams:")
+		for _, t := range teams {
+			fmt.Printf("  %s (%s)\n", t.Name, t.Permission)
+		}
+	}
+	return nil
+}
+
+// orgFlags are shared by the commands creating and editing organizations
+var orgFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "full-name",
+		Usage: "Full name of the organization",
+	},
+	cli.StringFlag{
+		Name:  "description, d",
+		Usage: "Description of the organization",
+	},
+	cli.StringFlag{
+		Name:  "website",
+		Usage: "Website of the organization",
+	},
+	cli.StringFlag{
+		Name:  "location",
+		Usage: "Location of the organization",
+	},
+	cli.StringFlag{
+		Name:  "visibility",
+		Usage: "Visibility of the organization: public, limited or private",
+	},
+}
+
+// applyOrgFlags sets the fields of an organization given as flags
+func applyOrgFlags(ctx *cli.Context, org *organization) error {
+	if ctx.IsSet("full-name") {
+		org.FullName = ctx.String("full-name")
+	}
+	if ctx.IsSet("description") {
+		org.Description = ctx.String("description")
+	}
+	if ctx.IsSet("website") {
+		org.Website = ctx.String("website")
+	}
+	if ctx.IsSet("location") {
+		org.Location = ctx.String("location")
+	}
+	if ctx.IsSet("visibility") {
+		v, err := parseOrgVisibility(ctx.String("visibility"))
+		if err != nil {
+			return err
+		}
+		org.Visibility = v
+	}
+	return nil
+}
+
+// CmdOrgsCreate represents a sub command of orgs to create an organization
+var CmdOrgsCreate = cli.Command{
+	Name:  "create",
+	Usage: "Create an organization",
+	Description: `Create an organization owned by the login's user. Administrators can create it
+for another user with --owner.`,
+	ArgsUsage: "<name>",
+	Action:    runOrgsCreate,
+	Flags: append(append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "owner",
+			Usage: "User owning the organization, requires an administrator",
+		},
+	}, orgFlags...), LoginFlag),
+}
+
+func runOrgsCreate(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return usageError("organization name is required")
+	}
+	org := &organization{UserName: ctx.Args().First()}
+	if err := applyOrgFlags(ctx, org); err != nil {
+		return err
+	}
+
+	login, err := initLoginCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	path := "/orgs"
+	if ctx.String("owner") != "" {
+		path = fmt.Sprintf("/admin/users/%s/orgs", ctx.String("owner"))
+	}
+	body, err := json.Marshal(org)
+	if err != nil {
+		return err
+	}
+	created := new(organization)
+	if err = login.getParsedResponse("POST", path, jsonHeader, bytes.NewReader(body), created); err != nil {
+		return err
+	}
+	fmt.Printf("Created organization %s\n", created.UserName)
+	return nil
+}
+
+// CmdOrgsEdit represents a sub command of orgs to edit an organization
+var CmdOrgsEdit = cli.Command{
+	Name:        "edit",
+	Usage:       "Change an organization",
+	Description: `Change the details of an organization, fields without a flag are kept`,
+	ArgsUsage:   "<organization>",
+	Action:      runOrgsEdit,
+	Flags:       append(orgFlags, LoginFlag),
+}
+
+// editOrgOption are the fields of an organization which can be changed,
+// the visibility is only sent when it is changed
+type editOrgOption struct {
+	FullName    string        `json:"full_name"`
+	Description string        `json:"description"`
+	Website     string        `json:"website"`
+	Location    string        `json:"location"`
+	Visibility  orgVisibility `json:"visibility,omitempty"`
+}
+
+func runOrgsEdit(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return usageError("organization name is required")
+	}
+	login, err := initLoginCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the API replaces all fields, so the unchanged ones are sent as well
+	org, err := getOrg(login, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	if err = applyOrgFlags(ctx, org); err != nil {
+		return err
+	}
+	opt := editOrgOption{
+		FullName:    org.FullName,
+		Description: org.Description,
+		Website:     org.Website,
+		Location:    org.Location,
+	}
+	// older servers send the visibility as number and may not take a name
+	if ctx.IsSet("visibility") {
+		opt.Visibility = org.Visibility
+	}
+
+	body, err := json.Marshal(opt)
+	if err != nil {
+		return err
+	}
+	if _, err = login.getResponse("PATCH", fmt.Sprintf("/orgs/%s", org.UserName), jsonHeader, bytes.NewReader(body)); err != nil {
+		return err
+	}
+	fmt.Printf("Updated organization %s\n", org.UserName)
+	return nil
+}
+
+// CmdOrgsMembers represents a sub command of orgs to list the members
+var CmdOrgsMembers = cli.Command{
+	Name:        "members",
+	Usage:       "List the members of an organization",
+	Description: `List the members of an organization, only members see the private ones`,
+	ArgsUsage:   "<organization>",
+	Action:      runOrgsMembers,
+	Flags:       append([]cli.Flag{LoginFlag}, OutputFlags...),
+}
+
+func runOrgsMembers(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return usageError("organization name is required")
+	}
+	login, err := initLoginCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	members := make([]*gitea.User, 0, 10)
+	if err = login.getParsedResponse("GET", fmt.Sprintf("/orgs/%s/members", ctx.Args().First()), nil, nil, &members); err != nil {
+		return err
+	}
+
+	if len(members) == 0 && !isStructuredOutput(ctx) {
+		fmt.Println("No members")
+		return nil
+	}
+	return printTable(ctx, usersTable(members))
+}
+
+func usersTable(users []*gitea.User) *output.Table {
+	t := &output.Table{
+		Columns: []string{"username", "full name", "email"},
+		Items:   users,
+	}
+	for _, u := range users {
+		t.AddRow(u.UserName, u.FullName, u.Email)
+	}
+	return t
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/output"
+
+	"github.com/urfave/cli"
+)
+
+// getOutputOptions returns the output options requested by the output
+// and format flags of the command or any of its parents
+func getOutputOptions(ctx *cli.Context) (output.Options, error) {
+	opt := output.Options{
+		Format:   strings.ToLower(getGlobalFlag(ctx, "output")),
+		Template: getGlobalFlag(ctx, "format"),
+	}
+	return opt, opt.Validate()
+}
+
+// printTable prints a table to stdout in the requested output format
+func printTable(ctx *cli.Context, t *output.Table) error {
+	opt, err := getOutputOptions(ctx)
+	if err != nil {
+		return err
+	}
+	return opt.Print(os.Stdout, t)
+}
+
+// printItem prints a single object to stdout in the requested output format
+func printItem(ctx *cli.Context, item interface{}, t *output.Table) error {
+	opt, err := getOutputOptions(ctx)
+	if err != nil {
+		return err
+	}
+	return opt.PrintItem(os.Stdout, item, t)
+}
+
+// isStructuredOutput reports whether a machine readable output was requested
+func isStructuredOutput(ctx *cli.Context) bool {
+	opt, _ := getOutputOptions(ctx)
+	return opt.IsStructured()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
+
+func formatUser(u *gitea.User) string {
+	if u == nil {
+		return ""
+	}
+	if len(u.FullName) != 0 {
+		return u.FullName
+	}
+	return u.UserName
+}
+
+func formatUsers(users []*gitea.User) string {
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		if u != nil {
+			names = append(names, u.UserName)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func formatLabels(labels []*gitea.Label) string {
+	names := make([]string, 0, len(labels))
+	for _, l := range labels {
+		if l != nil {
+			names = append(names, l.Name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func formatMilestone(m *gitea.Milestone) string {
+	if m == nil {
+		return ""
+	}
+	return m.Title
+}
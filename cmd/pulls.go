@@ -6,9 +6,10 @@ package cmd
 
 import (
 	"fmt"
-	"log"
+	"strconv"
 
 	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/output"
 
 	"github.com/urfave/cli"
 )
@@ -19,45 +20,61 @@ var CmdPulls = cli.Command{
 	Usage:       "Operate with pulls of the repository",
 	Description: `Operate with pulls of the repository`,
 	Action:      runPulls,
-	Flags: []cli.Flag{
-		cli.StringFlag{
-			Name:  "login, l",
-			Usage: "Indicate one login, optional when inside a gitea repository",
-		},
-		cli.StringFlag{
-			Name:  "repo, r",
-			Usage: "Indicate one repository, optional when inside a gitea repository",
-		},
+	Subcommands: []cli.Command{
+		CmdPullsCreate,
+		CmdPullsCheckout,
+		CmdPullsMerge,
+		CmdPullsClean,
 	},
+	Flags: append(append(listFlags, OutputFlags...), LoginRepoFlags...),
 }
 
 func runPulls(ctx *cli.Context) error {
-	login, owner, repo := initCommand(ctx)
+	opt, err := getListOptions(ctx)
+	if err != nil {
+		return err
+	}
 
-	prs, err := login.Client().ListRepoPullRequests(owner, repo, gitea.ListPullRequestsOptions{
-		Page:  0,
-		State: string(gitea.StateOpen),
-	})
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
 
+	prs, err := listRepoPullRequests(login, owner, repo, opt)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	if len(prs) == 0 {
+	if len(prs) == 0 && !isStructuredOutput(ctx) {
 		fmt.Println("No pull requests left")
 		return nil
 	}
 
+	return printTable(ctx, pullsTable(prs))
+}
+
+func pullsTable(prs []*gitea.PullRequest) *output.Table {
+	t := &output.Table{
+		Columns: []string{"index", "state", "author", "updated", "head", "base", "title"},
+		Items:   prs,
+	}
 	for _, pr := range prs {
-		if pr == nil {
-			continue
+		var head, base string
+		if pr.Head != nil {
+			head = pr.Head.Name
 		}
-		name := pr.Poster.FullName
-		if len(name) == 0 {
-			name = pr.Poster.UserName
+		if pr.Base != nil {
+			base = pr.Base.Name
 		}
-		fmt.Printf("#%d\t%s\t%s\t%s\n", pr.Index, name, pr.Updated.Format("2006-01-02 15:04:05"), pr.Title)
+		t.AddRow(
+			strconv.FormatInt(pr.Index, 10),
+			string(pr.State),
+			formatUser(pr.Poster),
+			formatTimePtr(pr.Updated),
+			head,
+			base,
+			pr.Title,
+		)
 	}
-
-	return nil
+	return t
 }
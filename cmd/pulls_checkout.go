@@ -0,0 +1,201 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+	local_git "code.gitea.io/tea/modules/git"
+
+	"github.com/urfave/cli"
+)
+
+// pullBranchPrefix is the prefix of the local branches created for pull requests
+const pullBranchPrefix = "pulls/"
+
+// CmdPullsCheckout is a command to locally checkout the given PR
+var CmdPullsCheckout = cli.Command{
+	Name:        "checkout",
+	Usage:       "Locally check out the given PR",
+	Description: `Locally check out the given PR into a pulls/<index>-<title> branch`,
+	ArgsUsage:   "<pull index>",
+	Action:      runPullsCheckout,
+	Flags:       LoginRepoFlags,
+}
+
+// CmdPullsClean is a command to remove the local branches of closed PRs
+var CmdPullsClean = cli.Command{
+	Name:        "clean",
+	Usage:       "Deletes local branches of merged or closed PRs",
+	Description: `Deletes the local pulls/<index>-<title> branches whose PRs are merged or closed`,
+	Action:      runPullsClean,
+	Flags:       LoginRepoFlags,
+}
+
+var slugRe = regexp.MustCompile("[^a-z0-9]+")
+
+// pullBranchName returns the local branch name for a pull request
+func pullBranchName(pr *gitea.PullRequest) string {
+	slug := strings.Trim(slugRe.ReplaceAllString(strings.ToLower(pr.Title), "-"), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimRight(slug[:40], "-")
+	}
+	if slug == "" {
+		return fmt.Sprintf("%s%d", pullBranchPrefix, pr.Index)
+	}
+	return fmt.Sprintf("%s%d-%s", pullBranchPrefix, pr.Index, slug)
+}
+
+func runPullsCheckout(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return usageError("pull request index is required")
+	}
+	idx, err := argToIndex(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	localRepo, err := local_git.CurrentRepo()
+	if err != nil {
+		return err
+	}
+
+	pr, err := login.Client().GetPullRequest(owner, repo, idx)
+	if err != nil {
+		return err
+	}
+
+	baseRemote, useSSH := findRemote(localRepo, login, owner+"/"+repo)
+	if baseRemote == "" {
+		return fmt.Errorf("no remote of this repository points to %s/%s", owner, repo)
+	}
+
+	// refs/pull/<index>/head always exists on the base repository,
+	// even when the head repository has been deleted in the meantime
+	if _, err = localRepo.Run("fetch", "--", baseRemote, fmt.Sprintf("refs/pull/%d/head", idx)); err != nil {
+		return err
+	}
+
+	branch := pullBranchName(pr)
+	if localRepo.HasBranch(branch) {
+		if _, err = localRepo.Run("checkout", branch, "--"); err != nil {
+			return err
+		}
+		if _, err = localRepo.Run("merge", "--ff-only", "FETCH_HEAD"); err != nil {
+			return err
+		}
+	} else if _, err = localRepo.Run("checkout", "-b", branch, "FETCH_HEAD", "--"); err != nil {
+		return err
+	}
+
+	if headRemote, err := ensureHeadRemote(localRepo, login, pr, baseRemote, useSSH); err != nil {
+		fmt.Println("Could not set up the remote of the head repository:", err)
+	} else if headRemote != "" {
+		if _, err = localRepo.Run("fetch", "--", headRemote, pr.Head.Ref); err == nil {
+			_, err = localRepo.Run("branch", "--set-upstream-to", headRemote+"/"+pr.Head.Ref)
+		}
+		if err != nil {
+			fmt.Println("Could not track the head branch:", err)
+		}
+	}
+
+	fmt.Printf("Checked out #%d into branch %s\n", pr.Index, branch)
+	return nil
+}
+
+// ensureHeadRemote returns the remote of the head repository of a pull
+// request, adding a remote named after the fork owner when needed
+func ensureHeadRemote(localRepo *local_git.Repo, login *Login, pr *gitea.PullRequest, baseRemote string, useSSH bool) (string, error) {
+	if pr.Head == nil || pr.Head.Repository == nil {
+		// the head repository was deleted, there is nothing to track
+		return "", nil
+	}
+	if pr.Base != nil && pr.Base.Repository != nil && pr.Head.Repository.ID == pr.Base.Repository.ID {
+		return baseRemote, nil
+	}
+
+	headRepo := pr.Head.Repository
+	if remote, _ := findRemote(localRepo, login, headRepo.FullName); remote != "" {
+		return remote, nil
+	}
+
+	name := headRepo.FullName
+	if headRepo.Owner != nil {
+		name = headRepo.Owner.UserName
+	}
+	if _, exists := localRepo.Config.Remotes[name]; exists {
+		return "", fmt.Errorf("remote %s already exists and points to another repository", name)
+	}
+
+	url := headRepo.CloneURL
+	if useSSH && headRepo.SSHURL != "" {
+		url = headRepo.SSHURL
+	}
+	if err := localRepo.AddRemote(name, url); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func runPullsClean(ctx *cli.Context) error {
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	localRepo, err := local_git.CurrentRepo()
+	if err != nil {
+		return err
+	}
+
+	branches, err := localRepo.Branches(strings.TrimSuffix(pullBranchPrefix, "/"))
+	if err != nil {
+		return err
+	}
+
+	current, _ := localRepo.CurrentBranch()
+	client := login.Client()
+	for _, branch := range branches {
+		name := strings.TrimPrefix(branch, pullBranchPrefix)
+		idx, err := strconv.ParseInt(strings.SplitN(name, "-", 2)[0], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		merged, err := client.IsPullRequestMerged(owner, repo, idx)
+		if err != nil {
+			return err
+		}
+		if !merged {
+			pr, err := client.GetPullRequest(owner, repo, idx)
+			if err != nil {
+				return err
+			}
+			if pr.State != gitea.StateClosed {
+				continue
+			}
+		}
+
+		if branch == current {
+			fmt.Printf("Skipping %s, it is checked out\n", branch)
+			continue
+		}
+		if _, err = localRepo.Run("branch", "-D", "--", branch); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted branch %s\n", branch)
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"testing"
+
+	"code.gitea.io/sdk/gitea"
+)
+
+func TestPullBranchName(t *testing.T) {
+	tests := []struct {
+		index    int64
+		title    string
+		expected string
+	}{
+		{1, "Fix the login", "pulls/1-fix-the-login"},
+		{2, "  [WIP] Add a `--yes` flag!  ", "pulls/2-wip-add-a-yes-flag"},
+		{3, "Ünïcödé only", "pulls/3-n-c-d-only"},
+		{4, "!!!", "pulls/4"},
+		{5, "", "pulls/5"},
+		// slugs are cut at 40 characters without a trailing dash
+		{6, "a very long title which is cut at forty characters", "pulls/6-a-very-long-title-which-is-cut-at-forty"},
+		{7, "abcdefghij abcdefghij abcdefghij abcdef ghij", "pulls/7-abcdefghij-abcdefghij-abcdefghij-abcdef"},
+	}
+
+	for _, tt := range tests {
+		pr := &gitea.PullRequest{Index: tt.index, Title: tt.title}
+		if actual := pullBranchName(pr); actual != tt.expected {
+			t.Errorf("pullBranchName(%q) = %q, expected %q", tt.title, actual, tt.expected)
+		}
+	}
+}
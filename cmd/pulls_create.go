@@ -0,0 +1,205 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+	local_git "code.gitea.io/tea/modules/git"
+	"code.gitea.io/tea/modules/utils"
+
+	"github.com/urfave/cli"
+)
+
+// CmdPullsCreate creates a pull request
+var CmdPullsCreate = cli.Command{
+	Name:  "create",
+	Usage: "Create a pull request",
+	Description: `Create a pull request from the current branch. The base defaults to the
+default branch of the repository, title and body default to the commit messages.`,
+	Action: runPullsCreate,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "head",
+			Usage: "Branch name of the PR source, use owner:branch for forks (default is current local branch)",
+		},
+		cli.StringFlag{
+			Name:  "base, B",
+			Usage: "Branch name of the PR target (default is the repository default branch)",
+		},
+		cli.StringFlag{
+			Name:  "title, t",
+			Usage: "pull request title",
+		},
+		cli.StringFlag{
+			Name:  "body, b",
+			Usage: "pull request body",
+		},
+	}, append(issueMetaFlags, LoginRepoFlags...)...),
+}
+
+func runPullsCreate(ctx *cli.Context) error {
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+	client := login.Client()
+
+	// the local repository is only needed to infer the defaults
+	localRepo, _ := local_git.CurrentRepo()
+
+	base := ctx.String("base")
+	if base == "" {
+		r, err := client.GetRepo(owner, repo)
+		if err != nil {
+			return err
+		}
+		base = r.DefaultBranch
+	}
+
+	head := ctx.String("head")
+	var localBranch string
+	if head == "" {
+		if localRepo == nil {
+			return usageError("--head is required outside of a git repository")
+		}
+		var err error
+		if head, localBranch, err = inferPullHead(localRepo, login, owner, repo); err != nil {
+			return err
+		}
+	}
+	if head == base {
+		return fmt.Errorf("head and base are both %s", base)
+	}
+
+	title, body := ctx.String("title"), ctx.String("body")
+	if title == "" && localRepo != nil && localBranch != "" {
+		baseRef := base
+		if remote, _ := findRemote(localRepo, login, owner+"/"+repo); remote != "" {
+			baseRef = remote + "/" + base
+		}
+		// the commits only provide defaults, e.g. the base may not be fetched
+		if commits, err := localRepo.Log(baseRef, localBranch); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not read the commits of %s: %v\n", localBranch, err)
+		} else {
+			var defaultBody string
+			title, defaultBody = pullMessageFromCommits(localBranch, commits)
+			if body == "" {
+				body = defaultBody
+			}
+		}
+	}
+	// the defaults from the commits are only suggestions when interactive
+	prompted := isInteractive() && !ctx.IsSet("title")
+	if isInteractive() {
+		var err error
+		if prompted {
+			if title, err = utils.PromptRequired("Title", title); err != nil {
+				return err
+			}
+		}
+		if !ctx.IsSet("body") {
+			if body, err = editBody(body, "description of the pull request"); err != nil {
+				return err
+			}
+		}
+	}
+	if title == "" {
+		return usageError("title is required")
+	}
+
+	deadline, err := parseDeadline(ctx.String("deadline"))
+	if err != nil {
+		return err
+	}
+
+	meta, err := getIssueMeta(ctx, client, owner, repo, prompted)
+	if err != nil {
+		return err
+	}
+	milestone, labels, err := meta.resolve(login, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	pr, err := client.CreatePullRequest(owner, repo, gitea.CreatePullRequestOption{
+		Head:      head,
+		Base:      base,
+		Title:     title,
+		Body:      body,
+		Assignees: meta.Assignees,
+		Deadline:  deadline,
+		Milestone: milestone,
+		Labels:    labels,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("#%d %s\n%s\n", pr.Index, pr.Title, pr.HTMLURL)
+	return nil
+}
+
+// inferPullHead returns the head of a pull request for the checked out
+// branch, it is prefixed with the fork owner when the branch tracks a
+// branch of another repository than owner/repo
+func inferPullHead(localRepo *local_git.Repo, login *Login, owner, repo string) (string, string, error) {
+	branch, err := localRepo.CurrentBranch()
+	if err != nil {
+		return "", "", errors.New("could not detect the current branch, use --head")
+	}
+
+	remote, remoteBranch, err := localRepo.Upstream(branch)
+	if err != nil {
+		// the branch has not been pushed with tracking information,
+		// assume it has been pushed under the same name
+		return branch, branch, nil
+	}
+
+	urls, err := localRepo.RemoteURLs(remote)
+	if err != nil {
+		return remoteBranch, branch, nil
+	}
+	for _, u := range urls {
+		p, err := local_git.ParseURL(u)
+		if err != nil {
+			continue
+		}
+		if path, ok := login.matchRemote(p); ok {
+			headOwner, _ := splitRepo(path)
+			if !strings.EqualFold(headOwner, owner) {
+				return headOwner + ":" + remoteBranch, branch, nil
+			}
+			break
+		}
+	}
+	return remoteBranch, branch, nil
+}
+
+// pullMessageFromCommits derives title and body of a pull request, a
+// single commit is used as is while multiple commits are listed
+func pullMessageFromCommits(branch string, commits []local_git.Commit) (string, string) {
+	switch len(commits) {
+	case 0:
+		return "", ""
+	case 1:
+		return commits[0].Subject, commits[0].Body
+	}
+
+	title := strings.NewReplacer("-", " ", "_", " ").Replace(branch[strings.LastIndex(branch, "/")+1:])
+	if title != "" {
+		title = strings.ToUpper(title[:1]) + title[1:]
+	}
+
+	lines := make([]string, 0, len(commits))
+	for _, c := range commits {
+		lines = append(lines, "- "+c.Subject)
+	}
+	return title, strings.Join(lines, "\n")
+}
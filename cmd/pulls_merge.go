@@ -0,0 +1,179 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"code.gitea.io/sdk/gitea"
+	local_git "code.gitea.io/tea/modules/git"
+
+	"github.com/urfave/cli"
+)
+
+// CmdPullsMerge merges a pull request
+var CmdPullsMerge = cli.Command{
+	Name:  "merge",
+	Usage: "Merge a pull request",
+	Description: `Merge a pull request. The merge is refused when the PR is already merged,
+not mergeable or the commit status of its head is not successful, unless --force is given.
+Heads without any commit status, e.g. in repositories without CI, need --force as well.`,
+	ArgsUsage: "<pull index>",
+	Action:    runPullsMerge,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "style, s",
+			Value: "merge",
+			Usage: "Merge style: merge, rebase, rebase-merge or squash",
+		},
+		cli.StringFlag{
+			Name:  "title, t",
+			Usage: "Merge commit title",
+		},
+		cli.StringFlag{
+			Name:  "message, m",
+			Usage: "Merge commit message",
+		},
+		cli.BoolFlag{
+			Name:  "force, f",
+			Usage: "Merge even if the safety checks fail or the head has no commit status",
+		},
+		cli.BoolFlag{
+			Name:  "delete-branch, d",
+			Usage: "Delete the head branch after merging",
+		},
+	}, LoginRepoFlags...),
+}
+
+// mergePullRequestOption is the merge form of the Gitea API, the SDK
+// does not support choosing a merge style yet
+type mergePullRequestOption struct {
+	Do                string `json:"Do"`
+	MergeTitleField   string `json:"MergeTitleField,omitempty"`
+	MergeMessageField string `json:"MergeMessageField,omitempty"`
+}
+
+func runPullsMerge(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return usageError("pull request index is required")
+	}
+	idx, err := argToIndex(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	style := ctx.String("style")
+	switch style {
+	case "merge", "rebase", "rebase-merge", "squash":
+	default:
+		return usageError("unknown merge style %s, expected merge, rebase, rebase-merge or squash", style)
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+	client := login.Client()
+
+	pr, err := client.GetPullRequest(owner, repo, idx)
+	if err != nil {
+		return err
+	}
+
+	problem, err := checkPullMergeable(client, owner, repo, pr)
+	if err != nil {
+		return err
+	}
+	if problem != "" {
+		if !ctx.Bool("force") {
+			return fmt.Errorf("%s, use --force to merge anyway", problem)
+		}
+		fmt.Printf("Warning: %s\n", problem)
+	}
+
+	body, err := json.Marshal(mergePullRequestOption{
+		Do:                style,
+		MergeTitleField:   ctx.String("title"),
+		MergeMessageField: ctx.String("message"),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = login.getResponse("POST",
+		fmt.Sprintf("/repos/%s/%s/pulls/%d/merge", owner, repo, idx),
+		jsonHeader, bytes.NewReader(body)); err != nil {
+		return err
+	}
+
+	fmt.Printf("Merged #%d %s\n", pr.Index, pr.Title)
+
+	if ctx.Bool("delete-branch") {
+		return deletePullHeadBranch(login, pr)
+	}
+	return nil
+}
+
+// checkPullMergeable runs the pre-merge safety checks and returns the
+// reason why the pull request should not be merged, if any
+func checkPullMergeable(client *gitea.Client, owner, repo string, pr *gitea.PullRequest) (string, error) {
+	merged, err := client.IsPullRequestMerged(owner, repo, pr.Index)
+	if err != nil {
+		return "", err
+	}
+	if merged || pr.HasMerged {
+		return fmt.Sprintf("#%d is already merged", pr.Index), nil
+	}
+
+	if !pr.Mergeable {
+		return fmt.Sprintf("#%d is not mergeable", pr.Index), nil
+	}
+
+	if pr.Head == nil || pr.Head.Sha == "" {
+		return fmt.Sprintf("the head commit of #%d is unknown", pr.Index), nil
+	}
+	status, err := client.GetCombinedStatus(owner, repo, pr.Head.Sha)
+	if err != nil {
+		return "", err
+	}
+	// without any status the head is not known to be successful
+	if status.TotalCount == 0 {
+		return fmt.Sprintf("the head of #%d has no commit status", pr.Index), nil
+	}
+	if status.State != gitea.StatusSuccess {
+		return fmt.Sprintf("the commit status of #%d is %s", pr.Index, status.State), nil
+	}
+	return "", nil
+}
+
+// deletePullHeadBranch removes the head branch of a merged pull request
+// by pushing its deletion through the matching local remote
+func deletePullHeadBranch(login *Login, pr *gitea.PullRequest) error {
+	if pr.Head == nil || pr.Head.Repository == nil {
+		return errors.New("the head repository does not exist anymore")
+	}
+	if pr.Base != nil && pr.Base.Repository != nil && pr.Head.Repository.ID == pr.Base.Repository.ID &&
+		pr.Head.Ref == pr.Base.Repository.DefaultBranch {
+		return fmt.Errorf("refusing to delete the default branch %s", pr.Head.Ref)
+	}
+
+	localRepo, err := local_git.CurrentRepo()
+	if err != nil {
+		return fmt.Errorf("deleting the head branch needs a local clone: %v", err)
+	}
+
+	remote, _ := findRemote(localRepo, login, pr.Head.Repository.FullName)
+	if remote == "" {
+		return fmt.Errorf("no remote of this repository points to %s", pr.Head.Repository.FullName)
+	}
+
+	if _, err = localRepo.Run("push", "--delete", "--", remote, pr.Head.Ref); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted branch %s of %s\n", pr.Head.Ref, pr.Head.Repository.FullName)
+	return nil
+}
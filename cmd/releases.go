@@ -6,11 +6,15 @@ package cmd
 
 import (
 	"fmt"
-	"log"
+	"io/ioutil"
+	"net/http"
 	"os"
-	"path/filepath"
+	"strconv"
+	"strings"
 
 	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/output"
+	"code.gitea.io/tea/modules/utils"
 
 	"github.com/urfave/cli"
 )
@@ -23,40 +27,143 @@ var CmdReleases = cli.Command{
 	Action:      runReleases,
 	Subcommands: []cli.Command{
 		CmdReleaseCreate,
+		CmdReleaseEdit,
+		CmdReleaseDelete,
+		CmdReleaseShow,
+		CmdReleaseDownload,
+		CmdReleaseAssets,
 	},
-	Flags: []cli.Flag{
-		cli.StringFlag{
-			Name:  "login, l",
-			Usage: "Indicate one login, optional when inside a gitea repository",
-		},
-		cli.StringFlag{
-			Name:  "repo, r",
-			Usage: "Indicate one repository, optional when inside a gitea repository",
-		},
-	},
+	Flags: append(OutputFlags, LoginRepoFlags...),
 }
 
 func runReleases(ctx *cli.Context) error {
-	login, owner, repo := initCommand(ctx)
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
 
 	releases, err := login.Client().ListReleases(owner, repo)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	if len(releases) == 0 {
+	if len(releases) == 0 && !isStructuredOutput(ctx) {
 		fmt.Println("No Releases")
 		return nil
 	}
 
+	return printTable(ctx, releasesTable(releases))
+}
+
+func releasesTable(releases []*gitea.Release) *output.Table {
+	t := &output.Table{
+		Columns: []string{"tag", "title", "published", "draft", "prerelease", "tar_url"},
+		Items:   releases,
+	}
 	for _, release := range releases {
-		fmt.Printf("#%s\t%s\t%s\t%s\n", release.TagName,
+		t.AddRow(
+			release.TagName,
 			release.Title,
-			release.PublishedAt.Format("2006-01-02 15:04:05"),
-			release.TarURL)
+			formatTime(release.PublishedAt),
+			strconv.FormatBool(release.IsDraft),
+			strconv.FormatBool(release.IsPrerelease),
+			release.TarURL,
+		)
+	}
+	return t
+}
+
+// getReleaseByTag looks up the release of a tag, the API has no direct
+// way to do so, so all releases are searched page by page
+func getReleaseByTag(login *Login, owner, repo, tag string) (*gitea.Release, error) {
+	if tag == "" {
+		return nil, usageError("release tag is required")
+	}
+	for page := 1; ; page++ {
+		releases := make([]*gitea.Release, 0, 10)
+		err := login.getParsedResponse("GET",
+			fmt.Sprintf("/repos/%s/%s/releases?page=%d", owner, repo, page),
+			nil, nil, &releases)
+		if err != nil {
+			return nil, err
+		}
+		if len(releases) == 0 {
+			return nil, notFoundError("there is no release for tag %s", tag)
+		}
+		for _, r := range releases {
+			if r.TagName == tag {
+				return r, nil
+			}
+		}
+	}
+}
+
+func releaseAssetsTable(assets []*gitea.Attachment) *output.Table {
+	t := &output.Table{
+		Columns: []string{"id", "name", "size", "downloads", "url"},
+		Items:   assets,
+	}
+	for _, a := range assets {
+		t.AddRow(
+			strconv.FormatInt(a.ID, 10),
+			a.Name,
+			strconv.FormatInt(a.Size, 10),
+			strconv.FormatInt(a.DownloadCount, 10),
+			a.DownloadURL,
+		)
+	}
+	return t
+}
+
+// CmdReleaseShow represents a sub command of Release to show a release.
+var CmdReleaseShow = cli.Command{
+	Name:        "show",
+	Usage:       "Show a release and its assets",
+	Description: `Show a release and its assets`,
+	ArgsUsage:   "<tag>",
+	Action:      runReleaseShow,
+	Flags:       append(OutputFlags, LoginRepoFlags...),
+}
+
+func runReleaseShow(ctx *cli.Context) error {
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	release, err := getReleaseByTag(login, owner, repo, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	assets, err := login.Client().ListReleaseAttachments(owner, repo, release.ID)
+	if err != nil {
+		return err
 	}
+	release.Attachments = assets
 
-	return nil
+	if isStructuredOutput(ctx) {
+		return printItem(ctx, release, releasesTable([]*gitea.Release{release}))
+	}
+
+	var state string
+	if release.IsDraft {
+		state = " (draft)"
+	} else if release.IsPrerelease {
+		state = " (prerelease)"
+	}
+	var author string
+	if release.Publisher != nil {
+		author = release.Publisher.UserName
+	}
+	fmt.Printf("%s %s%s\n%s published %s on %s\n\n%s\n", release.TagName, release.Title, state,
+		author, formatTime(release.PublishedAt), release.Target, release.Note)
+
+	if len(assets) == 0 {
+		return nil
+	}
+	fmt.Println()
+	return printTable(ctx, releaseAssetsTable(assets))
 }
 
 // CmdReleaseCreate represents a sub command of Release to create release.
@@ -65,7 +172,7 @@ var CmdReleaseCreate = cli.Command{
 	Usage:       "Create a release in repository",
 	Description: `Create a release in repository`,
 	Action:      runReleaseCreate,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		cli.StringFlag{
 			Name:  "tag",
 			Usage: "release tag name",
@@ -92,47 +199,148 @@ var CmdReleaseCreate = cli.Command{
 		},
 		cli.StringSliceFlag{
 			Name:  "asset, a",
-			Usage: "a list of files to attach to the release",
+			Usage: "a list of files to attach to the release, glob patterns like 'dist/*' are expanded",
 		},
-	},
+		noChecksumsFlag,
+		cli.StringFlag{
+			Name:  "notes-file",
+			Usage: "read the release note from a file, - reads stdin",
+		},
+		cli.BoolFlag{
+			Name:  "generate-notes",
+			Usage: "append notes listing the pull requests merged since the previous tag",
+		},
+		cli.StringFlag{
+			Name:  "previous-tag",
+			Usage: "tag to generate the notes from (default is the highest version lower than --tag)",
+		},
+		cli.StringSliceFlag{
+			Name:  "notes-group",
+			Usage: "section of the generated notes as label,...=Heading, replaces the default sections",
+		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "print the release instead of creating it",
+		},
+	}, LoginRepoFlags...),
 }
 
 func runReleaseCreate(ctx *cli.Context) error {
-	login, owner, repo := initCommand(ctx)
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
 
-	release, err := login.Client().CreateRelease(owner, repo, gitea.CreateReleaseOption{
-		TagName:      ctx.String("tag"),
-		Target:       ctx.String("target"),
-		Title:        ctx.String("title"),
-		Note:         ctx.String("note"),
-		IsDraft:      ctx.Bool("draft"),
-		IsPrerelease: ctx.Bool("prerelease"),
-	})
+	// fail on missing files before the release exists
+	assets, err := expandAssetPatterns(ctx.StringSlice("asset"))
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsSet("note") && ctx.String("notes-file") != "" {
+		return usageError("--note and --notes-file can not be combined")
+	}
+
+	tag, title := ctx.String("tag"), ctx.String("title")
+	interactive := isInteractive() && !ctx.Bool("dry-run")
+	if tag == "" && interactive {
+		if tag, err = utils.PromptRequired("Tag", ""); err != nil {
+			return err
+		}
+	}
+	if tag == "" {
+		return usageError("release tag is required")
+	}
+	if title == "" && interactive {
+		if title, err = utils.PromptRequired("Title", tag); err != nil {
+			return err
+		}
+	}
 
+	note, err := releaseNote(ctx, login, owner, repo, tag)
 	if err != nil {
-		if err.Error() == "409 Conflict" {
-			log.Fatal("error: There already is a release for this tag")
+		return err
+	}
+	if interactive && !ctx.IsSet("note") && ctx.String("notes-file") == "" {
+		if note, err = editBody(note, "release note"); err != nil {
+			return err
 		}
+	}
 
-		log.Fatal(err)
+	opt := gitea.CreateReleaseOption{
+		TagName:      tag,
+		Target:       ctx.String("target"),
+		Title:        title,
+		Note:         note,
+		IsDraft:      ctx.Bool("draft"),
+		IsPrerelease: ctx.Bool("prerelease"),
+	}
+	if ctx.Bool("dry-run") {
+		target := opt.Target
+		if target == "" {
+			target = "the default branch"
+		}
+		fmt.Printf("Release %s %s on %s, draft: %t, prerelease: %t\n", opt.TagName, opt.Title,
+			target, opt.IsDraft, opt.IsPrerelease)
+		for _, asset := range assets {
+			fmt.Printf("Asset %s\n", asset)
+		}
+		fmt.Printf("\n%s\n", opt.Note)
+		return nil
 	}
 
-	for _, asset := range ctx.StringSlice("asset") {
-		var file *os.File
+	release, err := login.Client().CreateRelease(owner, repo, opt)
 
-		if file, err = os.Open(asset); err != nil {
-			log.Fatal(err)
+	if err != nil {
+		if statusCode(err) == http.StatusConflict {
+			return conflictError("there already is a release for tag %s", opt.TagName)
 		}
+		return err
+	}
 
-		filePath := filepath.Base(asset)
+	if len(assets) == 0 {
+		return nil
+	}
+	return uploadReleaseAssets(login, owner, repo, release, assets, false, !ctx.Bool("no-checksums"))
+}
 
-		if _, err = login.Client().CreateReleaseAttachment(owner, repo, release.ID, file, filePath); err != nil {
-			file.Close()
-			log.Fatal(err)
+// releaseNote assembles the note of a new release from the note flags
+// and the generated notes
+func releaseNote(ctx *cli.Context, login *Login, owner, repo, tag string) (string, error) {
+	note := ctx.String("note")
+	if file := ctx.String("notes-file"); file != "" {
+		var bs []byte
+		var err error
+		if file == "-" {
+			bs, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			bs, err = ioutil.ReadFile(file)
 		}
+		if err != nil {
+			return "", err
+		}
+		note = strings.TrimSpace(string(bs))
+	}
 
-		file.Close()
+	if !ctx.Bool("generate-notes") {
+		return note, nil
+	}
+	defs := ctx.StringSlice("notes-group")
+	if len(defs) == 0 {
+		defs = defaultNotesGroups
+	}
+	groups, err := parseNotesGroups(defs)
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	generated, err := generateReleaseNotes(login, owner, repo, tag, ctx.String("target"),
+		ctx.String("previous-tag"), groups)
+	if err != nil {
+		return "", err
+	}
+	if note == "" {
+		return generated, nil
+	}
+	return note + "\n\n" + generated, nil
 }
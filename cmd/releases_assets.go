@@ -0,0 +1,453 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+
+	"github.com/urfave/cli"
+)
+
+// checksumsAsset is the name of the asset listing the checksums of all
+// other assets of a release
+const checksumsAsset = "SHA256SUMS"
+
+// CmdReleaseAssets represents a sub command of Release to manage assets.
+var CmdReleaseAssets = cli.Command{
+	Name:  "assets",
+	Usage: "Manage the assets of a release",
+	Description: `Manage the assets of a release. Uploading assets creates or updates a
+SHA256SUMS asset with their checksums, unless --no-checksums is given.`,
+	ArgsUsage: "<tag>",
+	Action:    runReleaseAssetsList,
+	Subcommands: []cli.Command{
+		CmdReleaseAssetsList,
+		CmdReleaseAssetsAdd,
+		CmdReleaseAssetsRemove,
+		CmdReleaseAssetsRename,
+	},
+	Flags: append(OutputFlags, LoginRepoFlags...),
+}
+
+// CmdReleaseAssetsList represents a sub command of assets to list them.
+var CmdReleaseAssetsList = cli.Command{
+	Name:        "ls",
+	Usage:       "List the assets of a release",
+	Description: `List the assets of a release`,
+	ArgsUsage:   "<tag>",
+	Action:      runReleaseAssetsList,
+	Flags:       append(OutputFlags, LoginRepoFlags...),
+}
+
+// CmdReleaseAssetsAdd represents a sub command of assets to upload them.
+var CmdReleaseAssetsAdd = cli.Command{
+	Name:  "add",
+	Usage: "Upload assets to a release",
+	Description: `Upload assets to a release. Glob patterns like 'dist/*' are expanded,
+all files are tried even if some of them fail.`,
+	ArgsUsage: "<tag> <file>...",
+	Action:    runReleaseAssetsAdd,
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "replace",
+			Usage: "replace existing assets with the same name",
+		},
+		noChecksumsFlag,
+	}, LoginRepoFlags...),
+}
+
+// CmdReleaseAssetsRemove represents a sub command of assets to delete them.
+var CmdReleaseAssetsRemove = cli.Command{
+	Name:        "rm",
+	Usage:       "Delete assets of a release",
+	Description: `Delete assets of a release by name or id`,
+	ArgsUsage:   "<tag> <asset>...",
+	Action:      runReleaseAssetsRemove,
+	Flags:       LoginRepoFlags,
+}
+
+// CmdReleaseAssetsRename represents a sub command of assets to rename one.
+var CmdReleaseAssetsRename = cli.Command{
+	Name:        "rename",
+	Usage:       "Rename an asset of a release",
+	Description: `Rename an asset of a release, given by name or id`,
+	ArgsUsage:   "<tag> <asset> <new name>",
+	Action:      runReleaseAssetsRename,
+	Flags:       LoginRepoFlags,
+}
+
+var noChecksumsFlag = cli.BoolFlag{
+	Name:  "no-checksums",
+	Usage: "do not create or update the SHA256SUMS asset with the checksums of all assets",
+}
+
+func runReleaseAssetsList(ctx *cli.Context) error {
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	release, err := getReleaseByTag(login, owner, repo, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	assets, err := login.Client().ListReleaseAttachments(owner, repo, release.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(assets) == 0 && !isStructuredOutput(ctx) {
+		fmt.Println("No assets")
+		return nil
+	}
+	return printTable(ctx, releaseAssetsTable(assets))
+}
+
+func runReleaseAssetsAdd(ctx *cli.Context) error {
+	if ctx.NArg() < 2 {
+		return usageError("release tag and at least one file are required")
+	}
+	files, err := expandAssetPatterns(ctx.Args().Tail())
+	if err != nil {
+		return err
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	release, err := getReleaseByTag(login, owner, repo, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	return uploadReleaseAssets(login, owner, repo, release, files, ctx.Bool("replace"), !ctx.Bool("no-checksums"))
+}
+
+func runReleaseAssetsRemove(ctx *cli.Context) error {
+	if ctx.NArg() < 2 {
+		return usageError("release tag and at least one asset are required")
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+	client := login.Client()
+
+	release, err := getReleaseByTag(login, owner, repo, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	assets, err := client.ListReleaseAttachments(owner, repo, release.ID)
+	if err != nil {
+		return err
+	}
+
+	removed := make(map[string]string)
+	for _, arg := range ctx.Args().Tail() {
+		asset := findAsset(assets, arg)
+		if asset == nil {
+			return notFoundError("release %s has no asset %s", release.TagName, arg)
+		}
+		if err = client.DeleteReleaseAttachment(owner, repo, release.ID, asset.ID); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted %s\n", asset.Name)
+		removed[asset.Name] = ""
+	}
+
+	return updateChecksums(login, owner, repo, release, removed, false)
+}
+
+func runReleaseAssetsRename(ctx *cli.Context) error {
+	if ctx.NArg() != 3 {
+		return usageError("release tag, asset and new name are required")
+	}
+	newName := ctx.Args().Get(2)
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+	client := login.Client()
+
+	release, err := getReleaseByTag(login, owner, repo, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	assets, err := client.ListReleaseAttachments(owner, repo, release.ID)
+	if err != nil {
+		return err
+	}
+
+	asset := findAsset(assets, ctx.Args().Get(1))
+	if asset == nil {
+		return notFoundError("release %s has no asset %s", release.TagName, ctx.Args().Get(1))
+	}
+	if findAssetByName(assets, newName) != nil {
+		return conflictError("release %s already has an asset %s", release.TagName, newName)
+	}
+
+	if _, err = client.EditReleaseAttachment(owner, repo, release.ID, asset.ID, gitea.EditAttachmentOptions{
+		Name: newName,
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("Renamed %s to %s\n", asset.Name, newName)
+
+	sums, err := readChecksums(login, assets)
+	if err != nil || sums == nil {
+		return err
+	}
+	changes := map[string]string{asset.Name: "", newName: sums[asset.Name]}
+	return updateChecksums(login, owner, repo, release, changes, false)
+}
+
+// findAssetByName returns the asset with the given name
+func findAssetByName(assets []*gitea.Attachment, name string) *gitea.Attachment {
+	for _, a := range assets {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// findAsset returns the asset with the given name or id
+func findAsset(assets []*gitea.Attachment, nameOrID string) *gitea.Attachment {
+	if a := findAssetByName(assets, nameOrID); a != nil {
+		return a
+	}
+	if id, err := strconv.ParseInt(nameOrID, 10, 64); err == nil {
+		for _, a := range assets {
+			if a.ID == id {
+				return a
+			}
+		}
+	}
+	return nil
+}
+
+// expandAssetPatterns expands the glob patterns of the asset arguments,
+// a pattern which does not match anything is an error. Assets are named
+// after the files, so different files with the same name are an error too.
+func expandAssetPatterns(patterns []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	names := make(map[string]string)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, usageError("invalid pattern %s: %v", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, usageError("no files match %s", pattern)
+		}
+		for _, m := range matches {
+			fi, err := os.Stat(m)
+			if err != nil {
+				return nil, err
+			}
+			m = filepath.Clean(m)
+			if fi.IsDir() || seen[m] {
+				continue
+			}
+			name := filepath.Base(m)
+			if other, ok := names[name]; ok {
+				return nil, usageError("%s and %s would both be uploaded as asset %s", other, m, name)
+			}
+			seen[m] = true
+			names[name] = m
+			files = append(files, m)
+		}
+	}
+	return files, nil
+}
+
+// uploadReleaseAssets uploads all files to the release. A failing file
+// does not stop the others, the failures are reported at the end.
+func uploadReleaseAssets(login *Login, owner, repo string, release *gitea.Release, files []string, replace, checksums bool) error {
+	client := login.Client()
+	assets, err := client.ListReleaseAttachments(owner, repo, release.ID)
+	if err != nil {
+		return err
+	}
+
+	uploaded := make(map[string]string)
+	var failed []string
+	for _, file := range files {
+		name := filepath.Base(file)
+		if name == checksumsAsset {
+			fmt.Fprintf(os.Stderr, "Skipping %s, it is generated by tea\n", file)
+			continue
+		}
+
+		existing := findAssetByName(assets, name)
+		if existing != nil && !replace {
+			fmt.Fprintf(os.Stderr, "Failed to upload %s: the release already has an asset %s, use --replace\n", file, name)
+			failed = append(failed, file)
+			continue
+		}
+
+		sum, err := uploadAsset(client, owner, repo, release.ID, file, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to upload %s: %v\n", file, err)
+			failed = append(failed, file)
+			continue
+		}
+		// the old asset is only removed once its replacement is there
+		if existing != nil {
+			if err = client.DeleteReleaseAttachment(owner, repo, release.ID, existing.ID); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to delete the replaced asset %s: %v\n", name, err)
+				failed = append(failed, file)
+			}
+		}
+		uploaded[name] = sum
+		fmt.Printf("Uploaded %s\n", file)
+	}
+
+	if len(uploaded) > 0 && checksums {
+		if err = updateChecksums(login, owner, repo, release, uploaded, true); err != nil {
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d assets failed to upload: %s", len(failed), len(files), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// uploadAsset uploads a single file and returns its SHA256 checksum
+func uploadAsset(client *gitea.Client, owner, repo string, releaseID int64, file, name string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err = client.CreateReleaseAttachment(owner, repo, releaseID, io.TeeReader(f, hash), name); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readChecksums parses the SHA256SUMS asset, it returns nil if there is none
+func readChecksums(login *Login, assets []*gitea.Attachment) (map[string]string, error) {
+	asset := findAssetByName(assets, checksumsAsset)
+	if asset == nil {
+		return nil, nil
+	}
+
+	body, err := openAsset(login, asset)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s failed: %v", checksumsAsset, err)
+	}
+	defer body.Close()
+
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			sums[strings.TrimPrefix(fields[1], "*")] = fields[0]
+		}
+	}
+	return sums, scanner.Err()
+}
+
+// updateChecksums applies changes to the SHA256SUMS asset of the release,
+// an empty checksum removes the entry. The asset is only created when
+// create is set, otherwise only an existing one is updated.
+func updateChecksums(login *Login, owner, repo string, release *gitea.Release, changes map[string]string, create bool) error {
+	client := login.Client()
+	assets, err := client.ListReleaseAttachments(owner, repo, release.ID)
+	if err != nil {
+		return err
+	}
+
+	sums, err := readChecksums(login, assets)
+	if err != nil {
+		return err
+	}
+	if sums == nil {
+		if !create {
+			return nil
+		}
+		sums = make(map[string]string)
+		// assets uploaded before have to be downloaded to get their checksum
+		for _, a := range assets {
+			if _, ok := changes[a.Name]; ok {
+				continue
+			}
+			sum, err := assetChecksum(login, a)
+			if err != nil {
+				return fmt.Errorf("computing the checksum of %s failed: %v", a.Name, err)
+			}
+			sums[a.Name] = sum
+		}
+	}
+
+	for name, sum := range changes {
+		if sum == "" {
+			delete(sums, name)
+		} else {
+			sums[name] = sum
+		}
+	}
+
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s  %s\n", sums[name], name)
+	}
+
+	old := findAssetByName(assets, checksumsAsset)
+	if _, err = client.CreateReleaseAttachment(owner, repo, release.ID, &buf, checksumsAsset); err != nil {
+		return fmt.Errorf("uploading %s failed: %v", checksumsAsset, err)
+	}
+	if old != nil {
+		if err = client.DeleteReleaseAttachment(owner, repo, release.ID, old.ID); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Updated %s\n", checksumsAsset)
+	return nil
+}
+
+// assetChecksum downloads an asset to compute its SHA256 checksum
+func assetChecksum(login *Login, asset *gitea.Attachment) (string, error) {
+	body, err := openAsset(login, asset)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
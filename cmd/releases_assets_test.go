@@ -0,0 +1,64 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandAssetPatterns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tea-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"linux/tea", "linux/tea.sha256", "darwin/tea", "docs/README.md"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := func(name string) string {
+		return filepath.Join(dir, filepath.FromSlash(name))
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		expected []string
+		err      string
+	}{
+		{"glob", []string{path("linux/*")}, []string{path("linux/tea"), path("linux/tea.sha256")}, ""},
+		{"same file twice", []string{path("linux/tea"), path("linux/../linux/tea"), path("linux/t*")},
+			[]string{path("linux/tea"), path("linux/tea.sha256")}, ""},
+		{"directories are skipped", []string{path("*"), path("docs/*")}, []string{path("docs/README.md")}, ""},
+		{"same name", []string{path("linux/*"), path("darwin/*")}, nil, "would both be uploaded as asset tea"},
+		{"no match", []string{path("windows/*")}, nil, "no files match"},
+		{"invalid pattern", []string{path("[")}, nil, "invalid pattern"},
+	}
+
+	for _, tt := range tests {
+		files, err := expandAssetPatterns(tt.patterns)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected error %q, got %v", tt.name, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if !reflect.DeepEqual(files, tt.expected) {
+			t.Errorf("%s: expandAssetPatterns() = %v, expected %v", tt.name, files, tt.expected)
+		}
+	}
+}
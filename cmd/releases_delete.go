@@ -0,0 +1,67 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+
+	local_git "code.gitea.io/tea/modules/git"
+
+	"github.com/urfave/cli"
+)
+
+// CmdReleaseDelete represents a sub command of Release to delete a release.
+var CmdReleaseDelete = cli.Command{
+	Name:  "delete",
+	Usage: "Delete a release",
+	Description: `Delete a release. The tag is kept unless --delete-tag is given, it is deleted
+by pushing through the remote of the current repository pointing to the repository.`,
+	ArgsUsage: "<tag>",
+	Action:    runReleaseDelete,
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "delete-tag",
+			Usage: "delete the git tag as well",
+		},
+	}, LoginRepoFlags...),
+}
+
+func runReleaseDelete(ctx *cli.Context) error {
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	release, err := getReleaseByTag(login, owner, repo, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	// the local repository is needed before anything is deleted
+	var localRepo *local_git.Repo
+	var remote string
+	if ctx.Bool("delete-tag") {
+		if localRepo, err = local_git.CurrentRepo(); err != nil {
+			return fmt.Errorf("deleting the tag needs a local clone: %v", err)
+		}
+		if remote, _ = findRemote(localRepo, login, owner+"/"+repo); remote == "" {
+			return fmt.Errorf("no remote of this repository points to %s/%s", owner, repo)
+		}
+	}
+
+	if err = login.Client().DeleteRelease(owner, repo, release.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted release %s\n", release.TagName)
+
+	if localRepo == nil {
+		return nil
+	}
+	if _, err = localRepo.Run("push", "--delete", "--", remote, "refs/tags/"+release.TagName); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted tag %s\n", release.TagName)
+	return nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+
+	"github.com/urfave/cli"
+)
+
+// CmdReleaseDownload represents a sub command of Release to download assets.
+var CmdReleaseDownload = cli.Command{
+	Name:  "download",
+	Usage: "Download the assets of a release",
+	Description: `Download the assets of a release and print their SHA256 checksums in the
+format of sha256sum, so they can be verified with 'sha256sum -c'.`,
+	ArgsUsage: "<tag>",
+	Action:    runReleaseDownload,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "pattern, p",
+			Value: "*",
+			Usage: "only download assets whose name matches the glob pattern",
+		},
+		cli.StringFlag{
+			Name:  "dir, d",
+			Value: ".",
+			Usage: "directory to save the assets in",
+		},
+		cli.BoolFlag{
+			Name:  "force, f",
+			Usage: "overwrite existing files",
+		},
+	}, LoginRepoFlags...),
+}
+
+func runReleaseDownload(ctx *cli.Context) error {
+	pattern := ctx.String("pattern")
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return usageError("invalid pattern %s: %v", pattern, err)
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	release, err := getReleaseByTag(login, owner, repo, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	assets, err := login.Client().ListReleaseAttachments(owner, repo, release.ID)
+	if err != nil {
+		return err
+	}
+
+	dir := ctx.String("dir")
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var found bool
+	for _, asset := range assets {
+		if ok, _ := filepath.Match(pattern, asset.Name); !ok {
+			continue
+		}
+		found = true
+
+		path := filepath.Join(dir, filepath.Base(asset.Name))
+		if _, err = os.Stat(path); err == nil && !ctx.Bool("force") {
+			return conflictError("%s already exists, use --force to overwrite it", path)
+		}
+
+		sum, err := downloadAsset(login, asset, path)
+		if err != nil {
+			return fmt.Errorf("downloading %s failed: %v", asset.Name, err)
+		}
+		fmt.Printf("%s  %s\n", sum, path)
+	}
+
+	if !found {
+		return notFoundError("release %s has no assets matching %s", release.TagName, pattern)
+	}
+	return nil
+}
+
+// downloadAsset saves an asset to path and returns its SHA256 checksum,
+// the file is only created once the download is complete
+func downloadAsset(login *Login, asset *gitea.Attachment, path string) (string, error) {
+	body, err := openAsset(login, asset)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tea-download-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(tmp, hash), body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		return "", err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// openAsset starts the download of an asset, authenticated like the API
+// requests so assets of private repositories work as well. The token is
+// only sent to the server of the login, also when being redirected.
+func openAsset(login *Login, asset *gitea.Attachment) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", asset.DownloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(login.URL)
+	if err != nil {
+		return nil, err
+	}
+	token, err := login.getToken()
+	if err != nil {
+		return nil, err
+	}
+	if token != "" && sameOrigin(req.URL, base) {
+		req.Header.Set("Authorization", "token "+token)
+	}
+	httpClient, err := login.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	client := *httpClient
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !sameOrigin(req.URL, base) {
+			req.Header.Del("Authorization")
+		}
+		if httpClient.CheckRedirect != nil {
+			return httpClient.CheckRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// sameOrigin reports whether u has the scheme, host and port of base
+func sameOrigin(u, base *url.URL) bool {
+	return strings.EqualFold(u.Scheme, base.Scheme) && strings.EqualFold(hostWithPort(u), hostWithPort(base))
+}
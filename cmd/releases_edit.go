@@ -0,0 +1,83 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+
+	"code.gitea.io/sdk/gitea"
+
+	"github.com/urfave/cli"
+)
+
+// CmdReleaseEdit represents a sub command of Release to edit a release.
+var CmdReleaseEdit = cli.Command{
+	Name:        "edit",
+	Usage:       "Edit a release",
+	Description: `Edit a release, only the given flags are changed`,
+	ArgsUsage:   "<tag>",
+	Action:      runReleaseEdit,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "tag",
+			Usage: "new tag name of the release",
+		},
+		cli.StringFlag{
+			Name:  "target",
+			Usage: "release target refs, branch name or commit id",
+		},
+		cli.StringFlag{
+			Name:  "title, t",
+			Usage: "release title",
+		},
+		cli.StringFlag{
+			Name:  "note, n",
+			Usage: "release note",
+		},
+		cli.BoolFlag{
+			Name:  "draft, d",
+			Usage: "mark the release as draft, use --draft=false to publish it",
+		},
+		cli.BoolFlag{
+			Name:  "prerelease, p",
+			Usage: "mark the release as prerelease, use --prerelease=false to undo",
+		},
+	}, LoginRepoFlags...),
+}
+
+func runReleaseEdit(ctx *cli.Context) error {
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	release, err := getReleaseByTag(login, owner, repo, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	opt := gitea.EditReleaseOption{
+		TagName: ctx.String("tag"),
+		Target:  ctx.String("target"),
+		Title:   ctx.String("title"),
+		Note:    ctx.String("note"),
+	}
+	if ctx.IsSet("draft") {
+		draft := ctx.Bool("draft")
+		opt.IsDraft = &draft
+	}
+	if ctx.IsSet("prerelease") {
+		prerelease := ctx.Bool("prerelease")
+		opt.IsPrerelease = &prerelease
+	}
+
+	release, err = login.Client().EditRelease(owner, repo, release.ID, opt)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Release %s %s saved\n", release.TagName, release.Title)
+	return nil
+}
@@ -0,0 +1,328 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"code.gitea.io/sdk/gitea"
+)
+
+// defaultNotesGroups maps labels to the sections of generated release
+// notes, pull requests without any of the labels are listed last
+var defaultNotesGroups = []string{
+	"feature,enhancement=Features",
+	"bug,fix=Bug Fixes",
+	"docs,documentation=Documentation",
+}
+
+// otherChangesHeading is the section of pull requests matching no group
+const otherChangesHeading = "Other Changes"
+
+// notesGroup is a section of the release notes and the labels in it
+type notesGroup struct {
+	Heading string
+	Labels  []string
+}
+
+// parseNotesGroups parses group definitions of the form label,...=Heading
+func parseNotesGroups(defs []string) ([]notesGroup, error) {
+	groups := make([]notesGroup, 0, len(defs))
+	for _, def := range defs {
+		p := strings.SplitN(def, "=", 2)
+		if len(p) != 2 || strings.TrimSpace(p[1]) == "" || len(splitList(p[0])) == 0 {
+			return nil, usageError("invalid notes group %q, expected label,...=Heading", def)
+		}
+		groups = append(groups, notesGroup{
+			Heading: strings.TrimSpace(p[1]),
+			Labels:  splitList(p[0]),
+		})
+	}
+	return groups, nil
+}
+
+// matches reports whether a label belongs to the group, scoped labels
+// like kind/bug match the group label bug
+func (g notesGroup) matches(label string) bool {
+	label = strings.ToLower(label)
+	for _, l := range g.Labels {
+		l = strings.ToLower(l)
+		if label == l || strings.HasSuffix(label, "/"+l) {
+			return true
+		}
+	}
+	return false
+}
+
+// previousTag returns the tag released before tag, the highest version
+// lower than it, other tags like nightly are skipped. If tag is no version
+// at all the highest one is used.
+func previousTag(tags []*gitea.Tag, tag string) *gitea.Tag {
+	var prev *gitea.Tag
+	for _, t := range tags {
+		if t.Name == tag {
+			continue
+		}
+		if isVersion(tag) && (!isVersion(t.Name) || compareVersions(t.Name, tag) >= 0) {
+			continue
+		}
+		if prev == nil || compareVersions(t.Name, prev.Name) > 0 {
+			prev = t
+		}
+	}
+	return prev
+}
+
+func isVersion(tag string) bool {
+	tag = strings.TrimPrefix(strings.ToLower(tag), "v")
+	return tag != "" && unicode.IsDigit(rune(tag[0]))
+}
+
+// compareVersions compares two tags by their numeric parts, so that
+// v1.10.0 is higher than v1.9.2
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case errA == nil:
+			return 1
+		case errB == nil:
+			return -1
+		case pa[i] != pb[i]:
+			return strings.Compare(pa[i], pb[i])
+		}
+	}
+	switch {
+	case len(pa) < len(pb):
+		return releaseSuffixOrder(pb[len(pa)])
+	case len(pa) > len(pb):
+		return -releaseSuffixOrder(pa[len(pb)])
+	}
+	return 0
+}
+
+// releaseSuffixOrder compares a version without a further part to one
+// with the part, 1.0 is lower than 1.0.1 but higher than 1.0-rc1
+func releaseSuffixOrder(part string) int {
+	if _, err := strconv.Atoi(part); err == nil {
+		return -1
+	}
+	return 1
+}
+
+// versionParts splits a tag into runs of digits and other characters,
+// separators are dropped
+func versionParts(tag string) []string {
+	tag = strings.TrimPrefix(strings.ToLower(tag), "v")
+	var parts []string
+	var cur []rune
+	var digits bool
+	flush := func() {
+		if len(cur) > 0 {
+			parts = append(parts, string(cur))
+			cur = cur[:0]
+		}
+	}
+	for _, r := range tag {
+		switch {
+		case r == '.' || r == '-' || r == '_' || r == '+':
+			flush()
+		case unicode.IsDigit(r) != digits:
+			flush()
+			digits = !digits
+			cur = append(cur, r)
+		default:
+			cur = append(cur, r)
+		}
+	}
+	flush()
+	return parts
+}
+
+// generateReleaseNotes lists the pull requests merged into target since
+// the previous tag, grouped by their labels
+func generateReleaseNotes(login *Login, owner, repo, tag, target, prevTag string, groups []notesGroup) (string, error) {
+	client := login.Client()
+
+	if target == "" {
+		r, err := client.GetRepo(owner, repo)
+		if err != nil {
+			return "", err
+		}
+		target = r.DefaultBranch
+	}
+
+	tags, err := client.ListRepoTags(owner, repo)
+	if err != nil {
+		return "", err
+	}
+	if prevTag == "" {
+		if prev := previousTag(tags, tag); prev != nil {
+			prevTag = prev.Name
+		}
+	}
+	var since, until time.Time
+	if prevTag != "" {
+		if since, err = tagTime(login, owner, repo, tags, prevTag); err != nil {
+			return "", err
+		}
+	}
+	// regenerated notes of an existing tag end at its commit
+	for _, t := range tags {
+		if t.Name == tag {
+			if until, err = tagTime(login, owner, repo, tags, tag); err != nil {
+				return "", err
+			}
+			until = until.Add(mergeClockSkew)
+			break
+		}
+	}
+
+	prs, err := listMergedPullRequests(login, owner, repo, target, since, until)
+	if err != nil {
+		return "", err
+	}
+
+	sections := make([][]*gitea.PullRequest, len(groups)+1)
+	for _, pr := range prs {
+		section := len(groups)
+	groupsLoop:
+		for i, g := range groups {
+			for _, l := range pr.Labels {
+				if l != nil && g.matches(l.Name) {
+					section = i
+					break groupsLoop
+				}
+			}
+		}
+		sections[section] = append(sections[section], pr)
+	}
+
+	var buf bytes.Buffer
+	if prevTag != "" {
+		fmt.Fprintf(&buf, "Changes since %s:\n", prevTag)
+	}
+	if len(prs) == 0 {
+		buf.WriteString("\nNo pull requests have been merged.\n")
+		return buf.String(), nil
+	}
+	for i, section := range sections {
+		if len(section) == 0 {
+			continue
+		}
+		heading := otherChangesHeading
+		if i < len(groups) {
+			heading = groups[i].Heading
+		}
+		sort.Slice(section, func(a, b int) bool { return section[a].Index < section[b].Index })
+
+		fmt.Fprintf(&buf, "\n## %s\n\n", heading)
+		for _, pr := range section {
+			fmt.Fprintf(&buf, "* %s (#%d)", pr.Title, pr.Index)
+			if pr.Poster != nil {
+				fmt.Fprintf(&buf, " @%s", pr.Poster.UserName)
+			}
+			buf.WriteString("\n")
+		}
+	}
+	return buf.String(), nil
+}
+
+// mergeClockSkew is the time by which a pull request can be marked as
+// merged after the time of its merge commit
+const mergeClockSkew = time.Minute
+
+// listMergedPullRequests lists the pull requests merged into target after
+// since and, unless it is zero, not after until
+func listMergedPullRequests(login *Login, owner, repo, target string, since, until time.Time) ([]*gitea.PullRequest, error) {
+	var result []*gitea.PullRequest
+	for page := 1; ; page++ {
+		q := url.Values{}
+		q.Set("state", string(gitea.StateClosed))
+		q.Set("sort", "recentupdate")
+		q.Set("page", strconv.Itoa(page))
+
+		prs := make([]*gitea.PullRequest, 0, 10)
+		err := login.getParsedResponse("GET",
+			fmt.Sprintf("/repos/%s/%s/pulls?%s", owner, repo, q.Encode()), nil, nil, &prs)
+		if err != nil {
+			return nil, err
+		}
+		if len(prs) == 0 {
+			return result, nil
+		}
+
+		older := !since.IsZero()
+		for _, pr := range prs {
+			if pr == nil {
+				continue
+			}
+			if pr.Updated == nil || pr.Updated.After(since) {
+				older = false
+			}
+			if isMergedBetween(pr, target, since, until) {
+				result = append(result, pr)
+			}
+		}
+		// merging updates a pull request, so the pages of the ones updated
+		// last before since hold no later merges
+		if older {
+			return result, nil
+		}
+	}
+}
+
+// isMergedBetween reports whether the pull request was merged into target
+// after since and, unless it is zero, not after until
+func isMergedBetween(pr *gitea.PullRequest, target string, since, until time.Time) bool {
+	if !pr.HasMerged || pr.Merged == nil || !pr.Merged.After(since) {
+		return false
+	}
+	if !until.IsZero() && pr.Merged.After(until) {
+		return false
+	}
+	return pr.Base == nil || pr.Base.Ref == target
+}
+
+// tagTime returns the commit time of a tag
+func tagTime(login *Login, owner, repo string, tags []*gitea.Tag, name string) (time.Time, error) {
+	var sha string
+	for _, t := range tags {
+		if t.Name == name {
+			sha = t.Commit.SHA
+			break
+		}
+	}
+	if sha == "" {
+		return time.Time{}, notFoundError("tag %s does not exist", name)
+	}
+
+	// the SDK uses a path for single commits which Gitea does not serve
+	commit := new(gitea.Commit)
+	if err := login.getParsedResponse("GET",
+		fmt.Sprintf("/repos/%s/%s/git/commits/%s", owner, repo, sha), nil, nil, commit); err != nil {
+		return time.Time{}, err
+	}
+	if commit.RepoCommit == nil || commit.RepoCommit.Committer == nil {
+		return time.Time{}, fmt.Errorf("commit %s of tag %s has no date", sha, name)
+	}
+	return time.Parse(time.RFC3339, commit.RepoCommit.Committer.Date)
+}
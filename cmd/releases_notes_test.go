@@ -0,0 +1,231 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"v1.10.0", "v1.9.2", 1},
+		{"v1.9.2", "v1.10.0", -1},
+		{"1.0.0", "v1.0.0", 0},
+		{"V2", "v10", -1},
+		{"1.0", "1.0.1", -1},
+		{"1.0.1", "1.0", 1},
+		// pre-releases are lower than the release
+		{"1.0", "1.0-rc1", 1},
+		{"1.0.0-rc1", "1.0.0", -1},
+		{"1.0.0-rc1", "1.0.0-rc2", -1},
+		{"1.0.0-rc2", "1.0.0-rc10", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0_beta", "1.0.0-beta", 0},
+		{"1.0.1", "1.0.rc", 1},
+		{"v1.0.0", "nightly", 1},
+	}
+
+	for _, tt := range tests {
+		if actual := compareVersions(tt.a, tt.b); actual != tt.expected {
+			t.Errorf("compareVersions(%q, %q) = %d, expected %d", tt.a, tt.b, actual, tt.expected)
+		}
+	}
+}
+
+func TestVersionParts(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected []string
+	}{
+		{"v1.10.0", []string{"1", "10", "0"}},
+		{"1.0.0-rc1", []string{"1", "0", "0", "rc", "1"}},
+		{"V2.0+build.5", []string{"2", "0", "build", "5"}},
+		{"nightly", []string{"nightly"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if actual := versionParts(tt.tag); !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("versionParts(%q) = %q, expected %q", tt.tag, actual, tt.expected)
+		}
+	}
+}
+
+func TestPreviousTag(t *testing.T) {
+	var tags []*gitea.Tag
+	for _, name := range []string{"v1.9.2", "nightly", "v1.10.1", "v1.10.0", "v1.11.0-rc1", "v1.8.0"} {
+		tags = append(tags, &gitea.Tag{Name: name})
+	}
+
+	tests := []struct {
+		tag      string
+		expected string
+	}{
+		{"v1.10.1", "v1.10.0"},
+		{"v1.10.0", "v1.9.2"},
+		{"v1.11.0-rc1", "v1.10.1"},
+		{"v1.11.0", "v1.11.0-rc1"},
+		{"v2.0.0", "v1.11.0-rc1"},
+		{"v1.8.0", ""},
+		{"v1.0.0", ""},
+		// tags which are no versions follow the highest version
+		{"nightly", "v1.11.0-rc1"},
+		{"latest", "v1.11.0-rc1"},
+	}
+
+	for _, tt := range tests {
+		prev := previousTag(tags, tt.tag)
+		actual := ""
+		if prev != nil {
+			actual = prev.Name
+		}
+		if actual != tt.expected {
+			t.Errorf("previousTag(%q) = %q, expected %q", tt.tag, actual, tt.expected)
+		}
+	}
+}
+
+func TestParseNotesGroups(t *testing.T) {
+	groups, err := parseNotesGroups([]string{"feature, enhancement = Features", "kind/bug=Bug Fixes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []notesGroup{
+		{Heading: "Features", Labels: []string{"feature", "enhancement"}},
+		{Heading: "Bug Fixes", Labels: []string{"kind/bug"}},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("parseNotesGroups() = %+v, expected %+v", groups, expected)
+	}
+
+	for _, def := range []string{"feature", "feature=", "=Features", " , =Features"} {
+		if _, err := parseNotesGroups([]string{def}); err == nil {
+			t.Errorf("parseNotesGroups(%q) succeeded, expected an error", def)
+		}
+	}
+}
+
+func TestNotesGroupMatches(t *testing.T) {
+	g := notesGroup{Heading: "Bug Fixes", Labels: []string{"bug", "Fix"}}
+	tests := []struct {
+		label    string
+		expected bool
+	}{
+		{"bug", true},
+		{"BUG", true},
+		{"fix", true},
+		{"kind/bug", true},
+		{"bugfix", false},
+		{"kind/bugs", false},
+		{"feature", false},
+	}
+
+	for _, tt := range tests {
+		if actual := g.matches(tt.label); actual != tt.expected {
+			t.Errorf("matches(%q) = %v, expected %v", tt.label, actual, tt.expected)
+		}
+	}
+}
+
+func TestIsMergedBetween(t *testing.T) {
+	since := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC)
+	merged := func(tm time.Time, base string) *gitea.PullRequest {
+		return &gitea.PullRequest{HasMerged: true, Merged: &tm, Base: &gitea.PRBranchInfo{Ref: base}}
+	}
+	tests := []struct {
+		name     string
+		pr       *gitea.PullRequest
+		until    time.Time
+		expected bool
+	}{
+		{"not merged", &gitea.PullRequest{}, until, false},
+		{"merged in range", merged(since.Add(time.Hour), "master"), until, true},
+		{"merged at since", merged(since, "master"), until, false},
+		{"merged before since", merged(since.Add(-time.Hour), "master"), until, false},
+		{"merged at until", merged(until, "master"), until, true},
+		{"merged after until", merged(until.Add(time.Hour), "master"), until, false},
+		{"no upper bound", merged(until.Add(time.Hour), "master"), time.Time{}, true},
+		{"other base", merged(since.Add(time.Hour), "release/v1"), until, false},
+	}
+
+	for _, tt := range tests {
+		if actual := isMergedBetween(tt.pr, "master", since, tt.until); actual != tt.expected {
+			t.Errorf("%s: isMergedBetween() = %v, expected %v", tt.name, actual, tt.expected)
+		}
+	}
+}
+
+func TestListMergedPullRequests(t *testing.T) {
+	since := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC)
+	pull := func(index int64, tm time.Time) *gitea.PullRequest {
+		return &gitea.PullRequest{Index: index, HasMerged: true, Merged: &tm, Updated: &tm,
+			Base: &gitea.PRBranchInfo{Ref: "master"}}
+	}
+	// pages of pull requests by recent update, the fourth one is only needed
+	// without a previous tag
+	pages := [][]*gitea.PullRequest{
+		{pull(4, until.Add(time.Hour)), pull(3, until.Add(-time.Hour))},
+		{pull(2, since.Add(time.Hour)), pull(1, since.Add(-time.Hour))},
+		{pull(0, since.Add(-2*time.Hour))},
+	}
+	var requested []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repos/owner/repo/pulls" {
+			http.NotFound(w, r)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		requested = append(requested, page)
+		prs := []*gitea.PullRequest{}
+		if page >= 1 && page <= len(pages) {
+			prs = pages[page-1]
+		}
+		json.NewEncoder(w).Encode(prs)
+	}))
+	defer srv.Close()
+	login := &Login{Name: "test", URL: srv.URL}
+
+	tests := []struct {
+		name      string
+		since     time.Time
+		until     time.Time
+		indexes   []int64
+		requested []int
+	}{
+		{"bounded", since, until, []int64{3, 2}, []int{1, 2, 3}},
+		{"no upper bound", since, time.Time{}, []int64{4, 3, 2}, []int{1, 2, 3}},
+		{"first release", time.Time{}, until, []int64{3, 2, 1, 0}, []int{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		requested = nil
+		prs, err := listMergedPullRequests(login, "owner", "repo", "master", tt.since, tt.until)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var indexes []int64
+		for _, pr := range prs {
+			indexes = append(indexes, pr.Index)
+		}
+		if !reflect.DeepEqual(indexes, tt.indexes) {
+			t.Errorf("%s: listed pull requests %v, expected %v", tt.name, indexes, tt.indexes)
+		}
+		if !reflect.DeepEqual(requested, tt.requested) {
+			t.Errorf("%s: requested pages %v, expected %v", tt.name, requested, tt.requested)
+		}
+	}
+}
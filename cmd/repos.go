@@ -0,0 +1,229 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/output"
+
+	"github.com/urfave/cli"
+)
+
+// CmdRepos represents to operate repositories
+var CmdRepos = cli.Command{
+	Name:        "repos",
+	Usage:       "Operate with repositories",
+	Description: `List, show, search, create, fork, delete and migrate repositories`,
+	Action:      runReposList,
+	Subcommands: []cli.Command{
+		CmdReposList,
+		CmdReposShow,
+		CmdReposSearch,
+		CmdReposCreate,
+		CmdReposFork,
+		CmdReposDelete,
+		CmdReposMigrate,
+		CmdReposMirrorSync,
+	},
+	Flags: CmdReposList.Flags,
+}
+
+// CmdReposList represents a sub command of repos to list repositories
+var CmdReposList = cli.Command{
+	Name:        "ls",
+	Usage:       "List repositories",
+	Description: `List the repositories of the login's user, or of another user or organization`,
+	Action:      runReposList,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "org",
+			Usage: "List the repositories of an organization",
+		},
+		cli.StringFlag{
+			Name:  "user",
+			Usage: "List the repositories of a user",
+		},
+		LoginFlag,
+	}, OutputFlags...),
+}
+
+func runReposList(ctx *cli.Context) error {
+	login, err := initLoginCommand(ctx)
+	if err != nil {
+		return err
+	}
+	client := login.Client()
+
+	var repos []*gitea.Repository
+	switch {
+	case ctx.String("org") != "":
+		repos, err = client.ListOrgRepos(ctx.String("org"))
+	case ctx.String("user") != "":
+		repos, err = client.ListUserRepos(ctx.String("user"))
+	default:
+		repos, err = client.ListMyRepos()
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(repos) == 0 && !isStructuredOutput(ctx) {
+		fmt.Println("No repositories")
+		return nil
+	}
+	return printTable(ctx, reposTable(repos))
+}
+
+func reposTable(repos []*gitea.Repository) *output.Table {
+	t := &output.Table{
+		Columns: []string{"name", "type", "visibility", "stars", "forks", "updated", "description"},
+		Items:   repos,
+	}
+	for _, r := range repos {
+		t.AddRow(r.FullName, repoType(r), repoVisibility(r), strconv.Itoa(r.Stars),
+			strconv.Itoa(r.Forks), formatTime(r.Updated), r.Description)
+	}
+	return t
+}
+
+func repoType(r *gitea.Repository) string {
+	switch {
+	case r.Fork:
+		return "fork"
+	case r.Mirror:
+		return "mirror"
+	}
+	return "source"
+}
+
+func repoVisibility(r *gitea.Repository) string {
+	if r.Private {
+		return "private"
+	}
+	return "public"
+}
+
+// CmdReposShow represents a sub command of repos to show a repository
+var CmdReposShow = cli.Command{
+	Name:        "show",
+	Usage:       "Show the details of a repository",
+	Description: `Show the details of a repository, by default the one of the current directory`,
+	ArgsUsage:   "[<owner>/<repo>]",
+	Action:      runReposShow,
+	Flags:       append(LoginRepoFlags, OutputFlags...),
+}
+
+func runReposShow(ctx *cli.Context) error {
+	login, owner, repo, err := initRepoArgCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	r, err := login.Client().GetRepo(owner, repo)
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput(ctx) {
+		return printItem(ctx, r, reposTable([]*gitea.Repository{r}))
+	}
+
+	state := repoVisibility(r)
+	if r.Fork && r.Parent != nil {
+		state += ", fork of " + r.Parent.FullName
+	} else if r.Mirror {
+		state += ", mirror"
+	}
+	if r.Archived {
+		state += ", archived"
+	}
+	fmt.Printf("%s (%s)\n", r.FullName, state)
+	if r.Description != "" {
+		fmt.Printf("%s\n", r.Description)
+	}
+	fmt.Println()
+	if r.Website != "" {
+		fmt.Printf("Website:        %s\n", r.Website)
+	}
+	fmt.Printf("Default branch: %s\n", r.DefaultBranch)
+	fmt.Printf("Stars:          %d\n", r.Stars)
+	fmt.Printf("Forks:          %d\n", r.Forks)
+	fmt.Printf("Watchers:       %d\n", r.Watchers)
+	fmt.Printf("Open issues:    %d\n", r.OpenIssues)
+	fmt.Printf("Created:        %s\n", formatTime(r.Created))
+	fmt.Printf("Updated:        %s\n", formatTime(r.Updated))
+	fmt.Printf("URL:            %s\n", r.HTMLURL)
+	fmt.Printf("Clone (HTTPS):  %s\n", r.CloneURL)
+	if r.SSHURL != "" {
+		fmt.Printf("Clone (SSH):    %s\n", r.SSHURL)
+	}
+	return nil
+}
+
+// CmdReposSearch represents a sub command of repos to search repositories
+var CmdReposSearch = cli.Command{
+	Name:        "search",
+	Usage:       "Search repositories",
+	Description: `Search the repositories visible to the login by name`,
+	ArgsUsage:   "<keyword>",
+	Action:      runReposSearch,
+	Flags: append([]cli.Flag{
+		cli.IntFlag{
+			Name:  "limit",
+			Value: 20,
+			Usage: "Maximum number of results",
+		},
+		LoginFlag,
+	}, OutputFlags...),
+}
+
+func runReposSearch(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return usageError("search keyword is required")
+	}
+	if ctx.Int("limit") <= 0 {
+		return usageError("invalid limit %d", ctx.Int("limit"))
+	}
+
+	login, err := initLoginCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	repos, err := searchRepos(login, ctx.Args().First(), ctx.Int("limit"))
+	if err != nil {
+		return err
+	}
+
+	if len(repos) == 0 && !isStructuredOutput(ctx) {
+		fmt.Println("No repositories found")
+		return nil
+	}
+	return printTable(ctx, reposTable(repos))
+}
+
+// searchRepos searches repositories by keyword, the SDK has no search
+func searchRepos(login *Login, keyword string, limit int) ([]*gitea.Repository, error) {
+	q := url.Values{}
+	q.Set("q", keyword)
+	q.Set("limit", strconv.Itoa(limit))
+
+	var result struct {
+		OK    bool                `json:"ok"`
+		Error string              `json:"error"`
+		Data  []*gitea.Repository `json:"data"`
+	}
+	if err := login.getParsedResponse("GET", "/repos/search?"+q.Encode(), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	if !result.OK && result.Error != "" {
+		return nil, fmt.Errorf("search failed: %s", result.Error)
+	}
+	return result.Data, nil
+}
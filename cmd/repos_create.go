@@ -0,0 +1,129 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+
+	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/utils"
+
+	"github.com/urfave/cli"
+)
+
+// CmdReposCreate represents a sub command of repos to create a repository
+var CmdReposCreate = cli.Command{
+	Name:        "create",
+	Usage:       "Create a repository",
+	Description: `Create a repository for the login's user or an organization`,
+	ArgsUsage:   "<name>",
+	Action:      runReposCreate,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "org",
+			Usage: "Create the repository in an organization",
+		},
+		cli.StringFlag{
+			Name:  "description, d",
+			Usage: "Description of the repository",
+		},
+		cli.BoolFlag{
+			Name:  "private",
+			Usage: "Make the repository private",
+		},
+		cli.BoolFlag{
+			Name:  "init",
+			Usage: "Initialize the repository with a README, .gitignore and license",
+		},
+		cli.StringFlag{
+			Name:  "gitignores",
+			Usage: "Comma-separated list of .gitignore templates used by --init",
+		},
+		cli.StringFlag{
+			Name:  "license",
+			Usage: "License template used by --init",
+		},
+		cli.StringFlag{
+			Name:  "readme",
+			Value: "Default",
+			Usage: "README template used by --init",
+		},
+		LoginFlag,
+	},
+}
+
+func runReposCreate(ctx *cli.Context) error {
+	login, err := initLoginCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	name := ctx.Args().First()
+	if name == "" {
+		if !isInteractive() {
+			return usageError("repository name is required")
+		}
+		if name, err = utils.PromptRequired("Name", ""); err != nil {
+			return err
+		}
+	}
+
+	opt := gitea.CreateRepoOption{
+		Name:        name,
+		Description: ctx.String("description"),
+		Private:     ctx.Bool("private"),
+		AutoInit:    ctx.Bool("init"),
+		Gitignores:  ctx.String("gitignores"),
+		License:     ctx.String("license"),
+		Readme:      ctx.String("readme"),
+	}
+
+	var repo *gitea.Repository
+	if org := ctx.String("org"); org != "" {
+		repo, err = login.Client().CreateOrgRepo(org, opt)
+	} else {
+		repo, err = login.Client().CreateRepo(opt)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created %s\n%s\n", repo.FullName, repo.HTMLURL)
+	return nil
+}
+
+// CmdReposFork represents a sub command of repos to fork a repository
+var CmdReposFork = cli.Command{
+	Name:        "fork",
+	Usage:       "Fork a repository",
+	Description: `Fork a repository, by default the one of the current directory`,
+	ArgsUsage:   "[<owner>/<repo>]",
+	Action:      runReposFork,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "org",
+			Usage: "Fork into an organization instead of the login's user",
+		},
+	}, LoginRepoFlags...),
+}
+
+func runReposFork(ctx *cli.Context) error {
+	login, owner, repo, err := initRepoArgCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	var opt gitea.CreateForkOption
+	if org := ctx.String("org"); org != "" {
+		opt.Organization = &org
+	}
+	fork, err := login.Client().CreateFork(owner, repo, opt)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Forked %s/%s to %s\n%s\n", owner, repo, fork.FullName, fork.HTMLURL)
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+
+	"code.gitea.io/tea/modules/utils"
+
+	"github.com/urfave/cli"
+)
+
+// CmdReposDelete represents a sub command of repos to delete a repository
+var CmdReposDelete = cli.Command{
+	Name:  "delete",
+	Usage: "Delete a repository",
+	Description: `Delete a repository with all its issues, pull requests and releases. The
+repository has to be named explicitly and the deletion confirmed by typing
+its name again, unless --yes is given.`,
+	ArgsUsage: "<owner>/<repo>",
+	Action:    runReposDelete,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "yes, y",
+			Usage: "Delete without asking for confirmation",
+		},
+		LoginFlag,
+	},
+}
+
+func runReposDelete(ctx *cli.Context) error {
+	// never fall back to the repository of the current directory
+	if !ctx.Args().Present() {
+		return usageError("repository is required")
+	}
+	login, owner, repo, err := initRepoArgCommand(ctx)
+	if err != nil {
+		return err
+	}
+	fullName := owner + "/" + repo
+
+	client := login.Client()
+	// fail before asking when the repository does not exist
+	if _, err = client.GetRepo(owner, repo); err != nil {
+		return err
+	}
+
+	if !ctx.Bool("yes") {
+		if !isInteractive() {
+			return usageError("use --yes to delete %s without confirmation", fullName)
+		}
+		answer, err := utils.Prompt(fmt.Sprintf("This deletes %s permanently, type its name to confirm: ", fullName))
+		if err != nil {
+			return err
+		}
+		if answer != fullName {
+			return fmt.Errorf("confirmation did not match, %s was not deleted", fullName)
+		}
+	}
+
+	if err = client.DeleteRepo(owner, repo); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted %s\n", fullName)
+	return nil
+}
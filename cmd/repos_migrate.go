@@ -0,0 +1,345 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/output"
+
+	"github.com/go-gitea/yaml"
+	"github.com/urfave/cli"
+)
+
+// CmdReposMigrate represents a sub command of repos to migrate repositories
+var CmdReposMigrate = cli.Command{
+	Name:  "migrate",
+	Usage: "Migrate repositories from other git hosts",
+	Description: `Migrate a repository into Gitea, or a batch of them listed in a manifest.
+
+A manifest is a YAML list or a CSV file with a header row, using the fields
+from, to, mirror, private, description, auth_user and auth_token:
+
+   - from: https://github.com/go-gitea/tea.git
+     to: gitea/tea
+     mirror: true
+
+Only from is required, the repository is named after the source and created
+for the login's user by default. The auth flags apply to all entries which
+have no credentials of their own.`,
+	Action: runReposMigrate,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "from",
+			Usage: "Clone URL of the repository to migrate",
+		},
+		cli.StringFlag{
+			Name:  "to",
+			Usage: "Owner and name of the new repository, <owner>/<repo>",
+		},
+		cli.BoolFlag{
+			Name:  "mirror",
+			Usage: "Keep the repository as a mirror of the source",
+		},
+		cli.BoolFlag{
+			Name:  "private",
+			Usage: "Make the repository private",
+		},
+		cli.StringFlag{
+			Name:  "description",
+			Usage: "Description of the repository",
+		},
+		cli.StringFlag{
+			Name:  "auth-user",
+			Usage: "Username for the source repository",
+		},
+		cli.StringFlag{
+			Name:   "auth-token",
+			EnvVar: "TEA_MIGRATE_AUTH_TOKEN",
+			Usage:  "Password or token for the source repository",
+		},
+		cli.StringFlag{
+			Name:  "manifest",
+			Usage: "YAML or CSV file listing the repositories to migrate",
+		},
+		LoginFlag,
+	}, OutputFlags...),
+}
+
+// migration is an entry of a migration manifest
+type migration struct {
+	From        string `yaml:"from" json:"from"`
+	To          string `yaml:"to" json:"to"`
+	Mirror      bool   `yaml:"mirror" json:"mirror"`
+	Private     bool   `yaml:"private" json:"private"`
+	Description string `yaml:"description" json:"description"`
+	AuthUser    string `yaml:"auth_user" json:"-"`
+	AuthToken   string `yaml:"auth_token" json:"-"`
+}
+
+// migrationResult is the outcome of one migration of a batch
+type migrationResult struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func runReposMigrate(ctx *cli.Context) error {
+	var migrations []migration
+	if file := ctx.String("manifest"); file != "" {
+		if ctx.IsSet("from") || ctx.IsSet("to") {
+			return usageError("--from and --to can not be combined with --manifest")
+		}
+		var err error
+		if migrations, err = readMigrationManifest(file); err != nil {
+			return err
+		}
+	} else {
+		if ctx.String("from") == "" {
+			return usageError("--from or --manifest is required")
+		}
+		migrations = []migration{{
+			From:        ctx.String("from"),
+			To:          ctx.String("to"),
+			Mirror:      ctx.Bool("mirror"),
+			Private:     ctx.Bool("private"),
+			Description: ctx.String("description"),
+		}}
+	}
+	for i := range migrations {
+		if migrations[i].AuthUser == "" && migrations[i].AuthToken == "" {
+			migrations[i].AuthUser = ctx.String("auth-user")
+			migrations[i].AuthToken = ctx.String("auth-token")
+		}
+	}
+
+	login, err := initLoginCommand(ctx)
+	if err != nil {
+		return err
+	}
+	client := login.Client()
+
+	if len(migrations) == 1 && ctx.String("manifest") == "" {
+		repo, err := migrateRepo(client, migrations[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Migrated %s to %s\n%s\n", migrations[0].From, repo.FullName, repo.HTMLURL)
+		return nil
+	}
+
+	results := make([]migrationResult, 0, len(migrations))
+	var failed int
+	for i, m := range migrations {
+		// progress goes to stderr, so the results can be piped
+		fmt.Fprintf(os.Stderr, "[%d/%d] Migrating %s ... ", i+1, len(migrations), m.From)
+		result := migrationResult{From: m.From, To: m.To, Status: "ok"}
+		repo, err := migrateRepo(client, m)
+		if err != nil {
+			result.Status, result.Error = "failed", ClassifyError(err).Error()
+			failed++
+			fmt.Fprintln(os.Stderr, "failed")
+		} else {
+			result.To = repo.FullName
+			fmt.Fprintln(os.Stderr, "ok")
+		}
+		results = append(results, result)
+	}
+
+	t := &output.Table{
+		Columns: []string{"from", "to", "status", "error"},
+		Items:   results,
+	}
+	for _, r := range results {
+		t.AddRow(r.From, r.To, r.Status, r.Error)
+	}
+	if err = printTable(ctx, t); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d migrations failed", failed, len(migrations))
+	}
+	return nil
+}
+
+// migrateRepo migrates one repository, the owner defaults to the login's
+// user and the name to the one of the source
+func migrateRepo(client *gitea.Client, m migration) (*gitea.Repository, error) {
+	owner, name := splitRepo(m.To)
+	if name == "" {
+		name = owner
+		owner = ""
+	}
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(strings.TrimRight(m.From, "/")), ".git")
+	}
+
+	var uid int64
+	if owner == "" {
+		u, err := client.GetMyUserInfo()
+		if err != nil {
+			return nil, err
+		}
+		uid = u.ID
+	} else {
+		// organizations are users as well for this endpoint
+		u, err := client.GetUserInfo(owner)
+		if err != nil {
+			return nil, err
+		}
+		uid = u.ID
+	}
+
+	return client.MigrateRepo(gitea.MigrateRepoOption{
+		CloneAddr:    m.From,
+		AuthUsername: m.AuthUser,
+		AuthPassword: m.AuthToken,
+		UID:          int(uid),
+		RepoName:     name,
+		Mirror:       m.Mirror,
+		Private:      m.Private,
+		Description:  m.Description,
+	})
+}
+
+// readMigrationManifest reads a YAML or CSV manifest, the format is
+// chosen by the file extension
+func readMigrationManifest(file string) ([]migration, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var migrations []migration
+	if strings.EqualFold(filepath.Ext(file), ".csv") {
+		migrations, err = parseMigrationCSV(f)
+	} else {
+		var bs []byte
+		if bs, err = ioutil.ReadAll(f); err == nil {
+			err = yaml.Unmarshal(bs, &migrations)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest %s failed: %v", file, err)
+	}
+
+	for i, m := range migrations {
+		if m.From == "" {
+			return nil, usageError("entry %d of manifest %s has no source", i+1, file)
+		}
+	}
+	if len(migrations) == 0 {
+		return nil, usageError("manifest %s lists no repositories", file)
+	}
+	return migrations, nil
+}
+
+func parseMigrationCSV(r io.Reader) ([]migration, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	// spreadsheets save UTF-8 with a byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	migrations := make([]migration, 0, len(records)-1)
+	for line, record := range records[1:] {
+		var m migration
+		for i, field := range header {
+			value := strings.TrimSpace(record[i])
+			switch strings.ToLower(strings.TrimSpace(field)) {
+			case "from":
+				m.From = value
+			case "to":
+				m.To = value
+			case "mirror", "private":
+				b, err := parseCSVBool(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", line+2, err)
+				}
+				if strings.EqualFold(strings.TrimSpace(field), "mirror") {
+					m.Mirror = b
+				} else {
+					m.Private = b
+				}
+			case "description":
+				m.Description = value
+			case "auth_user":
+				m.AuthUser = value
+			case "auth_token":
+				m.AuthToken = value
+			default:
+				return nil, fmt.Errorf("unknown column %s", field)
+			}
+		}
+		migrations = append(migrations, m)
+	}
+	return migrations, nil
+}
+
+func parseCSVBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// CmdReposMirrorSync represents a sub command of repos to sync mirrors
+var CmdReposMirrorSync = cli.Command{
+	Name:        "mirror-sync",
+	Usage:       "Update mirrors from their source",
+	Description: `Queue mirrors for an update from their source, by default the repository of the current directory`,
+	ArgsUsage:   "[<owner>/<repo>...]",
+	Action:      runReposMirrorSync,
+	Flags:       LoginRepoFlags,
+}
+
+func runReposMirrorSync(ctx *cli.Context) error {
+	var repos []string
+	var login *Login
+	var err error
+	if ctx.Args().Present() {
+		for _, arg := range ctx.Args() {
+			if owner, repo := splitRepo(arg); owner == "" || repo == "" {
+				return usageError("invalid repository %s, expected <owner>/<repo>", arg)
+			}
+		}
+		repos = ctx.Args()
+		if login, err = initLoginCommand(ctx); err != nil {
+			return err
+		}
+	} else {
+		var owner, repo string
+		if login, owner, repo, err = initCommand(ctx); err != nil {
+			return err
+		}
+		repos = []string{owner + "/" + repo}
+	}
+
+	client := login.Client()
+	for _, r := range repos {
+		owner, repo := splitRepo(r)
+		if err = client.MirrorSync(owner, repo); err != nil {
+			return err
+		}
+		fmt.Printf("Queued %s for mirror sync\n", r)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMigrationCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		expected []migration
+		err      string
+	}{
+		{
+			name: "all columns",
+			csv: `from,to,mirror,private,description,auth_user,auth_token
+https://github.com/go-gitea/tea,org/tea,true,false,"The CLI, for Gitea",alice,secret
+`,
+			expected: []migration{{
+				From: "https://github.com/go-gitea/tea", To: "org/tea", Mirror: true,
+				Description: "The CLI, for Gitea", AuthUser: "alice", AuthToken: "secret",
+			}},
+		},
+		{
+			name: "any column order and case",
+			csv:  "Private , FROM\n1, https://gitea.com/a/b \n0,https://gitea.com/c/d\n",
+			expected: []migration{
+				{From: "https://gitea.com/a/b", Private: true},
+				{From: "https://gitea.com/c/d"},
+			},
+		},
+		{
+			name:     "empty booleans",
+			csv:      "from,mirror,private\nhttps://gitea.com/a/b,,\n",
+			expected: []migration{{From: "https://gitea.com/a/b"}},
+		},
+		{
+			name:     "byte order mark",
+			csv:      "\ufefffrom,to\nhttps://gitea.com/a/b,b\n",
+			expected: []migration{{From: "https://gitea.com/a/b", To: "b"}},
+		},
+		{
+			name:     "header only",
+			csv:      "from,to\n",
+			expected: []migration{},
+		},
+		{
+			name: "empty",
+			csv:  "",
+		},
+		{
+			name: "invalid boolean",
+			csv:  "from,mirror\nhttps://gitea.com/a/b,true\nhttps://gitea.com/c/d,maybe\n",
+			err:  "line 3",
+		},
+		{
+			name: "unknown column",
+			csv:  "from,owner\nhttps://gitea.com/a/b,alice\n",
+			err:  "unknown column owner",
+		},
+		{
+			name: "missing field",
+			csv:  "from,to\nhttps://gitea.com/a/b\n",
+			err:  "wrong number of fields",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := parseMigrationCSV(strings.NewReader(tt.csv))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(migrations, tt.expected) {
+				t.Errorf("parseMigrationCSV() = %+v, expected %+v", migrations, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadMigrationManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tea-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		file    string
+		content string
+		count   int
+		err     string
+	}{
+		{"repos.yml", "- from: https://gitea.com/a/b\n  mirror: true\n- from: https://gitea.com/c/d\n", 2, ""},
+		{"repos.CSV", "from\nhttps://gitea.com/a/b\n", 1, ""},
+		{"missing-source.yml", "- to: b\n", 0, "entry 1 of manifest"},
+		{"empty.yml", "", 0, "lists no repositories"},
+		{"invalid.yml", "from: https://gitea.com/a/b\n", 0, "reading manifest"},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.file)
+		if err = ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		migrations, err := readMigrationManifest(path)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected error %q, got %v", tt.file, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.file, err)
+		} else if len(migrations) != tt.count {
+			t.Errorf("%s: read %d migrations, expected %d", tt.file, len(migrations), tt.count)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/output"
+
+	"github.com/urfave/cli"
+)
+
+// CmdTeams represents to operate the teams of an organization
+var CmdTeams = cli.Command{
+	Name:        "teams",
+	Usage:       "Operate with the teams of an organization",
+	Description: `List teams and add members and repositories to them`,
+	ArgsUsage:   "<organization>",
+	Action:      runTeamsList,
+	Subcommands: []cli.Command{
+		CmdTeamsList,
+		CmdTeamsAddMember,
+		CmdTeamsRemoveMember,
+		CmdTeamsAddRepo,
+	},
+	Flags: CmdTeamsList.Flags,
+}
+
+func listTeams(login *Login, org string) ([]*gitea.Team, error) {
+	teams := make([]*gitea.Team, 0, 10)
+	return teams, login.getParsedResponse("GET", fmt.Sprintf("/orgs/%s/teams", org), nil, nil, &teams)
+}
+
+// getTeam looks a team of an organization up by its name, ignoring the case
+func getTeam(login *Login, org, name string) (*gitea.Team, error) {
+	teams, err := listTeams(login, org)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range teams {
+		if strings.EqualFold(t.Name, name) {
+			return t, nil
+		}
+	}
+	return nil, notFoundError("team %s of %s does not exist", name, org)
+}
+
+// CmdTeamsList represents a sub command of teams to list them
+var CmdTeamsList = cli.Command{
+	Name:        "ls",
+	Usage:       "List the teams of an organization",
+	Description: `List the teams of an organization`,
+	ArgsUsage:   "<organization>",
+	Action:      runTeamsList,
+	Flags:       append([]cli.Flag{LoginFlag}, OutputFlags...),
+}
+
+func runTeamsList(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return usageError("organization name is required")
+	}
+	login, err := initLoginCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	teams, err := listTeams(login, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	if len(teams) == 0 && !isStructuredOutput(ctx) {
+		fmt.Println("No teams")
+		return nil
+	}
+	t := &output.Table{
+		Columns: []string{"id", "name", "permission", "description"},
+		Items:   teams,
+	}
+	for _, team := range teams {
+		t.AddRow(strconv.FormatInt(team.ID, 10), team.Name, team.Permission, team.Description)
+	}
+	return printTable(ctx, t)
+}
+
+// CmdTeamsAddMember represents a sub command of teams to add a user
+var CmdTeamsAddMember = cli.Command{
+	Name:        "add-member",
+	Usage:       "Add a user to a team",
+	Description: `Add a user to a team, which makes them a member of the organization`,
+	ArgsUsage:   "<organization> <team> <user>",
+	Action:      runTeamsAddMember,
+	Flags:       []cli.Flag{LoginFlag},
+}
+
+func runTeamsAddMember(ctx *cli.Context) error {
+	return changeTeam(ctx, "PUT", "members", "Added %s to team %s\n")
+}
+
+// CmdTeamsRemoveMember represents a sub command of teams to remove a user
+var CmdTeamsRemoveMember = cli.Command{
+	Name:        "remove-member",
+	Usage:       "Remove a user from a team",
+	Description: `Remove a user from a team`,
+	ArgsUsage:   "<organization> <team> <user>",
+	Action:      runTeamsRemoveMember,
+	Flags:       []cli.Flag{LoginFlag},
+}
+
+func runTeamsRemoveMember(ctx *cli.Context) error {
+	return changeTeam(ctx, "DELETE", "members", "Removed %s from team %s\n")
+}
+
+// CmdTeamsAddRepo represents a sub command of teams to give access to a
+// repository
+var CmdTeamsAddRepo = cli.Command{
+	Name:        "add-repo",
+	Usage:       "Give a team access to a repository",
+	Description: `Give a team access to a repository of the organization with the permission of the team`,
+	ArgsUsage:   "<organization> <team> <repo>",
+	Action:      runTeamsAddRepo,
+	Flags:       []cli.Flag{LoginFlag},
+}
+
+func runTeamsAddRepo(ctx *cli.Context) error {
+	return changeTeam(ctx, "PUT", "repos", "Added %s to team %s\n")
+}
+
+// changeTeam adds a member or repository to a team or removes it, the SDK
+// has no team API
+func changeTeam(ctx *cli.Context, method, kind, done string) error {
+	if ctx.NArg() != 3 {
+		return usageError("organization, team and %s are required", strings.TrimSuffix(kind, "s"))
+	}
+	org, name, target := ctx.Args().Get(0), ctx.Args().Get(1), ctx.Args().Get(2)
+
+	login, err := initLoginCommand(ctx)
+	if err != nil {
+		return err
+	}
+	team, err := getTeam(login, org, name)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/teams/%d/members/%s", team.ID, target)
+	if kind == "repos" {
+		// repositories are named with their owner, which is the organization
+		target = org + "/" + strings.TrimPrefix(target, org+"/")
+		path = fmt.Sprintf("/teams/%d/repos/%s", team.ID, target)
+	}
+	if _, err = login.getResponse(method, path, nil, nil); err != nil {
+		return err
+	}
+	fmt.Printf(done, target, team.Name)
+	return nil
+}
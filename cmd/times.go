@@ -0,0 +1,448 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+	"code.gitea.io/tea/modules/output"
+
+	"github.com/urfave/cli"
+)
+
+// CmdTimes represents to operate the tracked times of a repository
+var CmdTimes = cli.Command{
+	Name:        "times",
+	Usage:       "Operate on tracked times of the repository",
+	Description: `List and add the times tracked on issues and pull requests`,
+	Action:      runTimesList,
+	Subcommands: []cli.Command{
+		CmdTimesList,
+		CmdTimesAdd,
+	},
+	Flags: CmdTimesList.Flags,
+}
+
+// trackedTime is a tracked time including the user and issue, which are
+// only named by newer servers and looked up otherwise
+type trackedTime struct {
+	ID       int64      `json:"id"`
+	Created  time.Time  `json:"created"`
+	Time     int64      `json:"time"`
+	UserID   int64      `json:"user_id"`
+	UserName string     `json:"user_name"`
+	IssueID  int64      `json:"issue_id"`
+	Issue    *repoIssue `json:"issue"`
+}
+
+// repoIssue is an issue returned by the APIs across repositories, newer
+// servers name its repository
+type repoIssue struct {
+	*gitea.Issue
+	Repository *gitea.Repository `json:"repository"`
+}
+
+// CmdTimesList represents a sub command of times to list them
+var CmdTimesList = cli.Command{
+	Name:  "ls",
+	Usage: "List tracked times",
+	Description: `List the times tracked on the repository, on one issue, by one user or by the
+login's user on all repositories. With --totals the times are summed up per
+issue or user, --output csv gives a file for invoicing.`,
+	ArgsUsage: "[<issue index>]",
+	Action:    runTimesList,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "user, u",
+			Usage: "Only list the times of a user",
+		},
+		cli.BoolFlag{
+			Name:  "mine, m",
+			Usage: "List the times of the login's user on all repositories",
+		},
+		cli.StringFlag{
+			Name:  "from",
+			Usage: "Only list times tracked on or after the date, YYYY-MM-DD",
+		},
+		cli.StringFlag{
+			Name:  "to",
+			Usage: "Only list times tracked on or before the date, YYYY-MM-DD",
+		},
+		cli.StringFlag{
+			Name:  "totals",
+			Usage: "Sum the times up per issue or user",
+		},
+	}, append(OutputFlags, LoginRepoFlags...)...),
+}
+
+func runTimesList(ctx *cli.Context) error {
+	from, err := parseDeadline(ctx.String("from"))
+	if err != nil {
+		return err
+	}
+	to, err := parseDeadline(ctx.String("to"))
+	if err != nil {
+		return err
+	}
+	totals := ctx.String("totals")
+	if totals != "" && totals != "issue" && totals != "user" {
+		return usageError("unknown totals %s, expected issue or user", totals)
+	}
+
+	var login *Login
+	var owner, repo, path string
+	if ctx.Bool("mine") {
+		if ctx.Args().Present() || ctx.IsSet("user") {
+			return usageError("--mine can not be combined with an issue or --user")
+		}
+		if login, err = initLoginCommand(ctx); err != nil {
+			return err
+		}
+		path = "/user/times"
+	} else {
+		if login, owner, repo, err = initCommand(ctx); err != nil {
+			return err
+		}
+		switch {
+		case ctx.Args().Present():
+			idx, err := argToIndex(ctx.Args().First())
+			if err != nil {
+				return err
+			}
+			path = fmt.Sprintf("/repos/%s/%s/issues/%d/times", owner, repo, idx)
+		case ctx.String("user") != "":
+			path = fmt.Sprintf("/repos/%s/%s/times/%s", owner, repo, ctx.String("user"))
+		default:
+			path = fmt.Sprintf("/repos/%s/%s/times", owner, repo)
+		}
+	}
+
+	times := make([]*trackedTime, 0, 10)
+	if err = login.getParsedResponse("GET", path, nil, nil, &times); err != nil {
+		return err
+	}
+
+	filtered := times[:0]
+	for _, t := range times {
+		if from != nil && t.Created.Before(*from) {
+			continue
+		}
+		// the to date is inclusive
+		if to != nil && !t.Created.Before(to.AddDate(0, 0, 1)) {
+			continue
+		}
+		filtered = append(filtered, t)
+	}
+	times = filtered
+
+	// older servers send no user names, so they are resolved first
+	if err = resolveTrackedTimes(login, owner, repo, times); err != nil {
+		return err
+	}
+	if user := ctx.String("user"); user != "" {
+		filtered = times[:0]
+		for _, t := range times {
+			if strings.EqualFold(t.UserName, user) {
+				filtered = append(filtered, t)
+			}
+		}
+		times = filtered
+	}
+
+	if len(times) == 0 && !isStructuredOutput(ctx) {
+		fmt.Println("No tracked times")
+		return nil
+	}
+
+	var total int64
+	for _, t := range times {
+		total += t.Time
+	}
+	var table *output.Table
+	if totals != "" {
+		table = timeTotalsTable(times, totals)
+	} else {
+		table = timesTable(times)
+	}
+	if err = printTable(ctx, table); err != nil {
+		return err
+	}
+	if !isStructuredOutput(ctx) {
+		fmt.Printf("\nTotal: %s (%s hours)\n", formatDuration(total), formatHours(total))
+	}
+	return nil
+}
+
+// resolveTrackedTimes fills in the users and issues older servers do not
+// send, issues can only be looked up within a repository
+func resolveTrackedTimes(login *Login, owner, repo string, times []*trackedTime) error {
+	users := make(map[int64]string)
+	var issues map[int64]*repoIssue
+	for _, t := range times {
+		if t.UserName == "" {
+			name, ok := users[t.UserID]
+			if !ok {
+				name = lookupUserName(login, t.UserID)
+				users[t.UserID] = name
+			}
+			t.UserName = name
+		}
+		if t.Issue == nil && repo != "" {
+			if issues == nil {
+				var err error
+				if issues, err = listAllIssues(login, owner, repo); err != nil {
+					return err
+				}
+			}
+			t.Issue = issues[t.IssueID]
+		}
+	}
+	return nil
+}
+
+// lookupUserName returns the name of a user by ID, or the ID itself if
+// the user can not be found
+func lookupUserName(login *Login, id int64) string {
+	var result struct {
+		Data []*gitea.User `json:"data"`
+	}
+	err := login.getParsedResponse("GET", fmt.Sprintf("/users/search?uid=%d", id), nil, nil, &result)
+	if err == nil {
+		for _, u := range result.Data {
+			if u.ID == id {
+				return u.UserName
+			}
+		}
+	}
+	return "#" + strconv.FormatInt(id, 10)
+}
+
+// listAllIssues maps the IDs of all issues and pull requests of a
+// repository to them
+func listAllIssues(login *Login, owner, repo string) (map[int64]*repoIssue, error) {
+	result := make(map[int64]*repoIssue)
+	for page := 1; ; page++ {
+		issues := make([]*gitea.Issue, 0, 10)
+		err := login.getParsedResponse("GET",
+			fmt.Sprintf("/repos/%s/%s/issues?state=all&page=%d", owner, repo, page), nil, nil, &issues)
+		if err != nil {
+			return nil, err
+		}
+		if len(issues) == 0 {
+			return result, nil
+		}
+		for _, issue := range issues {
+			result[issue.ID] = &repoIssue{Issue: issue}
+		}
+	}
+}
+
+func timesTable(times []*trackedTime) *output.Table {
+	t := &output.Table{
+		Columns: []string{"created", "issue", "title", "user", "duration", "hours"},
+		Items:   times,
+	}
+	for _, tt := range times {
+		issue, title := trackedIssue(tt)
+		t.AddRow(formatTime(tt.Created), issue, title, tt.UserName, formatDuration(tt.Time), formatHours(tt.Time))
+	}
+	return t
+}
+
+// timeTotal is the sum of the tracked times of an issue or user
+type timeTotal struct {
+	Issue string `json:"issue,omitempty"`
+	Title string `json:"title,omitempty"`
+	User  string `json:"user,omitempty"`
+	Time  int64  `json:"time"`
+}
+
+func timeTotalsTable(times []*trackedTime, by string) *output.Table {
+	var totals []*timeTotal
+	index := make(map[string]*timeTotal)
+	for _, tt := range times {
+		var key string
+		total := &timeTotal{}
+		if by == "issue" {
+			total.Issue, total.Title = trackedIssue(tt)
+			key = total.Issue
+		} else {
+			total.User = tt.UserName
+			key = total.User
+		}
+		if existing, ok := index[key]; ok {
+			total = existing
+		} else {
+			index[key] = total
+			totals = append(totals, total)
+		}
+		total.Time += tt.Time
+	}
+	sort.SliceStable(totals, func(i, j int) bool { return totals[i].Time > totals[j].Time })
+
+	t := &output.Table{Items: totals}
+	if by == "issue" {
+		t.Columns = []string{"issue", "title", "duration", "hours"}
+	} else {
+		t.Columns = []string{"user", "duration", "hours"}
+	}
+	for _, total := range totals {
+		if by == "issue" {
+			t.AddRow(total.Issue, total.Title, formatDuration(total.Time), formatHours(total.Time))
+		} else {
+			t.AddRow(total.User, formatDuration(total.Time), formatHours(total.Time))
+		}
+	}
+	return t
+}
+
+// trackedIssue returns the reference and title of the issue of a time
+func trackedIssue(t *trackedTime) (string, string) {
+	if t.Issue == nil || t.Issue.Issue == nil {
+		return "id " + strconv.FormatInt(t.IssueID, 10), ""
+	}
+	ref := "#" + strconv.FormatInt(t.Issue.Index, 10)
+	if t.Issue.Repository != nil && t.Issue.Repository.FullName != "" {
+		ref = t.Issue.Repository.FullName + ref
+	}
+	return ref, t.Issue.Title
+}
+
+// formatDuration formats seconds like 1h30m
+func formatDuration(seconds int64) string {
+	d := time.Duration(seconds) * time.Second
+	h, m, s := int64(d.Hours()), int64(d.Minutes())%60, int64(d.Seconds())%60
+	var out string
+	if h > 0 {
+		out += fmt.Sprintf("%dh", h)
+	}
+	if m > 0 {
+		out += fmt.Sprintf("%dm", m)
+	}
+	if s > 0 || out == "" {
+		out += fmt.Sprintf("%ds", s)
+	}
+	return out
+}
+
+func formatHours(seconds int64) string {
+	return strconv.FormatFloat(float64(seconds)/3600, 'f', 2, 64)
+}
+
+// CmdTimesAdd represents a sub command of times to track time on an issue
+var CmdTimesAdd = cli.Command{
+	Name:        "add",
+	Usage:       "Track time on an issue",
+	Description: `Track time on an issue or pull request, the duration is given like 1h30m or 45m`,
+	ArgsUsage:   "<issue index> <duration>",
+	Action:      runTimesAdd,
+	Flags:       LoginRepoFlags,
+}
+
+func runTimesAdd(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return usageError("issue index and duration are required")
+	}
+	idx, err := argToIndex(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	d, err := time.ParseDuration(ctx.Args().Get(1))
+	if err != nil || d < time.Second {
+		return usageError("invalid duration %s, expected e.g. 1h30m", ctx.Args().Get(1))
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the SDK can not decode the issue of the response of newer servers
+	body, err := json.Marshal(gitea.AddTimeOption{Time: int64(d.Seconds())})
+	if err != nil {
+		return err
+	}
+	t := new(trackedTime)
+	if err = login.getParsedResponse("POST", fmt.Sprintf("/repos/%s/%s/issues/%d/times", owner, repo, idx),
+		jsonHeader, bytes.NewReader(body), t); err != nil {
+		return err
+	}
+	fmt.Printf("Added %s to #%d\n", formatDuration(t.Time), idx)
+	return nil
+}
+
+// CmdIssuesStart represents a sub command of issues to start a stopwatch
+var CmdIssuesStart = cli.Command{
+	Name:        "start",
+	Usage:       "Start the stopwatch of an issue",
+	Description: `Start tracking time on an issue, 'tea issues stop' adds the time`,
+	ArgsUsage:   "<issue index>",
+	Action:      runIssuesStart,
+	Flags:       LoginRepoFlags,
+}
+
+func runIssuesStart(ctx *cli.Context) error {
+	return issueStopwatch(ctx, true)
+}
+
+// CmdIssuesStop represents a sub command of issues to stop a stopwatch
+var CmdIssuesStop = cli.Command{
+	Name:        "stop",
+	Usage:       "Stop the stopwatch of an issue",
+	Description: `Stop tracking time on an issue and add the time since 'tea issues start'`,
+	ArgsUsage:   "<issue index>",
+	Action:      runIssuesStop,
+	Flags:       LoginRepoFlags,
+}
+
+func runIssuesStop(ctx *cli.Context) error {
+	return issueStopwatch(ctx, false)
+}
+
+func issueStopwatch(ctx *cli.Context, start bool) error {
+	if !ctx.Args().Present() {
+		return usageError("issue index is required")
+	}
+	idx, err := argToIndex(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	login, owner, repo, err := initCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	client := login.Client()
+	if start {
+		err = client.StartIssueStopWatch(owner, repo, idx)
+	} else {
+		err = client.StopIssueStopWatch(owner, repo, idx)
+	}
+	switch {
+	case err == nil:
+	case statusCode(err) == http.StatusConflict && start:
+		return conflictError("the stopwatch of #%d is already running", idx)
+	case statusCode(err) == http.StatusConflict:
+		return conflictError("the stopwatch of #%d is not running", idx)
+	default:
+		return err
+	}
+
+	if start {
+		fmt.Printf("Started the stopwatch of #%d\n", idx)
+	} else {
+		fmt.Printf("Stopped the stopwatch of #%d, the time was added\n", idx)
+	}
+	return nil
+}
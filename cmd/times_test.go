@@ -0,0 +1,47 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"code.gitea.io/sdk/gitea"
+)
+
+func TestTimeTotalsTable(t *testing.T) {
+	first := &repoIssue{Issue: &gitea.Issue{Index: 1, Title: "first"}}
+	second := &repoIssue{Issue: &gitea.Issue{Index: 2, Title: "second"}}
+	times := []*trackedTime{
+		{Time: 600, UserName: "alice", IssueID: 11, Issue: first},
+		{Time: 1200, UserName: "bob", IssueID: 11, Issue: first},
+		{Time: 3600, UserName: "alice", IssueID: 12, Issue: second},
+	}
+
+	tests := []struct {
+		by       string
+		expected []*timeTotal
+	}{
+		{"user", []*timeTotal{
+			{User: "alice", Time: 4200},
+			{User: "bob", Time: 1200},
+		}},
+		// the times of several users add up, so none is named
+		{"issue", []*timeTotal{
+			{Issue: "#2", Title: "second", Time: 3600},
+			{Issue: "#1", Title: "first", Time: 1800},
+		}},
+	}
+
+	for _, tt := range tests {
+		table := timeTotalsTable(times, tt.by)
+		if actual := table.Items.([]*timeTotal); !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("timeTotalsTable(%s) = %+v, expected %+v", tt.by, actual, tt.expected)
+		}
+		if len(table.Rows) != len(tt.expected) {
+			t.Errorf("timeTotalsTable(%s) has %d rows, expected %d", tt.by, len(table.Rows), len(tt.expected))
+		}
+	}
+}
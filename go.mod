@@ -6,6 +6,7 @@ require (
 	code.gitea.io/sdk v0.0.0-20190424055801-13a7bf625b83
 	github.com/go-gitea/yaml v0.0.0-20170812160011-eb3733d160e7
 	github.com/urfave/cli v1.20.0
+	golang.org/x/crypto v0.0.0-20180904163835-0709b304e793
 	gopkg.in/src-d/go-git.v4 v4.11.0
 	gopkg.in/yaml.v2 v2.2.2 // indirect
 )
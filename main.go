@@ -6,7 +6,7 @@
 package main // import "code.gitea.io/tea"
 
 import (
-	"log"
+	"fmt"
 	"os"
 	"strings"
 
@@ -33,16 +33,30 @@ func main() {
 	app.Usage = "Command line tool to interact with Gitea"
 	app.Description = ``
 	app.Version = Version + formatBuiltWith(Tags)
+	app.Flags = cmd.OutputFlags
 	app.Commands = []cli.Command{
 		cmd.CmdLogin,
 		cmd.CmdLogout,
 		cmd.CmdIssues,
 		cmd.CmdPulls,
 		cmd.CmdReleases,
+		cmd.CmdComment,
+		cmd.CmdRepos,
+		cmd.CmdLabels,
+		cmd.CmdMilestones,
+		cmd.CmdTimes,
+		cmd.CmdDashboard,
+		cmd.CmdOrgs,
+		cmd.CmdTeams,
+		cmd.CmdClone,
 	}
+	cmd.SetUsageErrorHandler(app)
+
 	err := app.Run(os.Args)
 	if err != nil {
-		log.Fatal(4, "Failed to run app with %s: %v", os.Args, err)
+		cerr := cmd.ClassifyError(err)
+		fmt.Fprintln(os.Stderr, "Error:", cerr)
+		os.Exit(cerr.Code)
 	}
 }
 
@@ -0,0 +1,173 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	fileKeyIterations = 100000
+	fileKeyLength     = 32
+)
+
+// ErrWrongPassphrase is returned when the encrypted file cannot be decrypted
+var ErrWrongPassphrase = errors.New("wrong passphrase for the credential file")
+
+// PassphraseFunc returns the passphrase of the encrypted file, confirm
+// is true when the file is about to be created
+type PassphraseFunc func(confirm bool) (string, error)
+
+type encryptedFile struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// FileStore keeps secrets in a file encrypted with AES-GCM, the key is
+// derived from a passphrase
+type FileStore struct {
+	path       string
+	passphrase PassphraseFunc
+
+	unlocked bool
+	key      string
+	secrets  map[string]string
+}
+
+// NewFileStore returns a store backed by the encrypted file at path
+func NewFileStore(path string, passphrase PassphraseFunc) *FileStore {
+	return &FileStore{
+		path:       path,
+		passphrase: passphrase,
+	}
+}
+
+// Get implements Store
+func (s *FileStore) Get(key Key) (string, error) {
+	if err := s.unlock(); err != nil {
+		return "", err
+	}
+	secret, ok := s.secrets[key.ID]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set implements Store
+func (s *FileStore) Set(key Key, secret string) error {
+	if err := s.unlock(); err != nil {
+		return err
+	}
+	s.secrets[key.ID] = secret
+	return s.save()
+}
+
+// Delete implements Store
+func (s *FileStore) Delete(key Key) error {
+	if err := s.unlock(); err != nil {
+		return err
+	}
+	if _, ok := s.secrets[key.ID]; !ok {
+		return nil
+	}
+	delete(s.secrets, key.ID)
+	return s.save()
+}
+
+// unlock asks for the passphrase once and decrypts the file
+func (s *FileStore) unlock() error {
+	if s.unlocked {
+		return nil
+	}
+
+	bs, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		if s.key, err = s.passphrase(true); err != nil {
+			return err
+		}
+		s.secrets = make(map[string]string)
+		s.unlocked = true
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var f encryptedFile
+	if err = json.Unmarshal(bs, &f); err != nil {
+		return err
+	}
+
+	if s.key, err = s.passphrase(false); err != nil {
+		return err
+	}
+	gcm, err := newGCM(s.key, f.Salt)
+	if err != nil {
+		return err
+	}
+	plain, err := gcm.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return ErrWrongPassphrase
+	}
+
+	s.secrets = make(map[string]string)
+	if err = json.Unmarshal(plain, &s.secrets); err != nil {
+		return err
+	}
+	s.unlocked = true
+	return nil
+}
+
+func (s *FileStore) save() error {
+	plain, err := json.Marshal(s.secrets)
+	if err != nil {
+		return err
+	}
+
+	f := encryptedFile{Salt: make([]byte, 16)}
+	if _, err = io.ReadFull(rand.Reader, f.Salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(s.key, f.Salt)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, f.Nonce); err != nil {
+		return err
+	}
+	f.Data = gcm.Seal(nil, f.Nonce, plain, nil)
+
+	bs, err := json.Marshal(&f)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, bs, 0600)
+}
+
+// fileKey derives the AES key of the file from the passphrase, changing it
+// makes existing files unreadable
+func fileKey(passphrase string, salt []byte) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, fileKeyIterations, fileKeyLength, sha256.New)
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(fileKey(passphrase, salt))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package credentials
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fixedPassphrase(passphrase string) PassphraseFunc {
+	return func(confirm bool) (string, error) {
+		return passphrase, nil
+	}
+}
+
+func TestFileKey(t *testing.T) {
+	// PBKDF2-HMAC-SHA256, existing files can only be read with the same key
+	key := hex.EncodeToString(fileKey("passphrase", []byte("0123456789abcdef")))
+	if expected := "1f39fd9702630e76894ea6dcb11d8a89a9930c72e48cb18454789fa3389ac5a9"; key != expected {
+		t.Errorf("fileKey() = %s, expected %s", key, expected)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tea-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "credentials")
+
+	key := Key{ID: "login-1", URL: "https://gitea.com"}
+	s := NewFileStore(path, fixedPassphrase("secret"))
+	if _, err = s.Get(key); err != ErrNotFound {
+		t.Fatalf("expected %v for an empty store, got %v", ErrNotFound, err)
+	}
+	if err = s.Set(key, "token-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Set(Key{ID: "login-2"}, "token-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(bs), "token-1") {
+		t.Error("the secret is stored in cleartext")
+	}
+
+	// a new store has to decrypt the file
+	s = NewFileStore(path, fixedPassphrase("secret"))
+	if secret, err := s.Get(key); err != nil || secret != "token-1" {
+		t.Errorf("Get() = %q, %v, expected token-1", secret, err)
+	}
+	if err = s.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Delete(key); err != nil {
+		t.Errorf("deleting a missing secret failed: %v", err)
+	}
+
+	s = NewFileStore(path, fixedPassphrase("secret"))
+	if _, err = s.Get(key); err != ErrNotFound {
+		t.Errorf("expected %v for a deleted secret, got %v", ErrNotFound, err)
+	}
+	if secret, err := s.Get(Key{ID: "login-2"}); err != nil || secret != "token-2" {
+		t.Errorf("Get() = %q, %v, expected token-2", secret, err)
+	}
+
+	s = NewFileStore(path, fixedPassphrase("wrong"))
+	if _, err = s.Get(key); err != ErrWrongPassphrase {
+		t.Errorf("expected %v, got %v", ErrWrongPassphrase, err)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// HelperStore talks to a credential helper using the protocol of
+// git-credential(1). With an empty helper name the helpers configured
+// for git are used through `git credential`.
+type HelperStore struct {
+	helper string
+}
+
+// NewHelperStore returns a store using the named credential helper, the
+// name is interpreted the same way as git's credential.helper setting
+func NewHelperStore(helper string) *HelperStore {
+	return &HelperStore{helper: helper}
+}
+
+// Get implements Store
+func (s *HelperStore) Get(key Key) (string, error) {
+	out, err := s.run("get", key)
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "password=") {
+			return strings.TrimPrefix(scanner.Text(), "password="), nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// Set implements Store
+func (s *HelperStore) Set(key Key, secret string) error {
+	_, err := s.run("store", key, "password="+secret)
+	return err
+}
+
+// Delete implements Store
+func (s *HelperStore) Delete(key Key) error {
+	_, err := s.run("erase", key)
+	return err
+}
+
+// run invokes the helper with an action and the credential description
+func (s *HelperStore) run(action string, key Key, extra ...string) ([]byte, error) {
+	u, err := url.Parse(key.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var input bytes.Buffer
+	fmt.Fprintf(&input, "protocol=%s\n", u.Scheme)
+	fmt.Fprintf(&input, "host=%s\n", u.Host)
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		fmt.Fprintf(&input, "path=%s\n", path)
+	}
+	// the username keeps the tea token apart from the git credentials
+	// stored for the same host
+	fmt.Fprintf(&input, "username=%s@tea\n", key.ID)
+	for _, line := range extra {
+		fmt.Fprintln(&input, line)
+	}
+	input.WriteString("\n")
+
+	cmd := s.command(action)
+	cmd.Stdin = &input
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credential helper %s failed: %v", action, err)
+	}
+	return out, nil
+}
+
+func (s *HelperStore) command(action string) *exec.Cmd {
+	switch {
+	case s.helper == "":
+		// use the helpers configured for git itself
+		gitAction := map[string]string{"get": "fill", "store": "approve", "erase": "reject"}[action]
+		return exec.Command("git", "credential", gitAction)
+	case strings.HasPrefix(s.helper, "!"):
+		return exec.Command("sh", "-c", s.helper[1:]+` "$@"`, "credential-helper", action)
+	case filepath.IsAbs(s.helper):
+		fields := strings.Fields(s.helper)
+		return exec.Command(fields[0], append(fields[1:], action)...)
+	}
+	fields := strings.Fields(s.helper)
+	return exec.Command("git", append([]string{"credential-" + fields[0]}, append(fields[1:], action)...)...)
+}
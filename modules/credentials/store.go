@@ -0,0 +1,35 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package credentials
+
+import "errors"
+
+// Supported credential store backends
+const (
+	// BackendPlain keeps the tokens in cleartext inside the tea config
+	BackendPlain = "plain"
+	// BackendFile keeps the tokens in a passphrase encrypted file
+	BackendFile = "file"
+	// BackendHelper delegates to a git credential helper
+	BackendHelper = "helper"
+)
+
+// ErrNotFound is returned when a store has no secret for a key
+var ErrNotFound = errors.New("credential not found")
+
+// Key identifies a secret inside a store
+type Key struct {
+	// ID is a stable identifier chosen when the secret is stored
+	ID string
+	// URL is the server the secret belongs to
+	URL string
+}
+
+// Store persists secrets outside of the tea config
+type Store interface {
+	Get(key Key) (string, error)
+	Set(key Key, secret string) error
+	Delete(key Key) error
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Clone clones url into dir and opens the new repository, the progress
+// of git is shown on the terminal
+func Clone(url, dir string) (*Repo, error) {
+	// url and dir must never be taken for options
+	cmd := exec.Command("git", "clone", "--", url, dir)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git clone %s: %v", url, err)
+	}
+	return FindRepo(dir)
+}
+
+// Run executes git with the given arguments inside the repository and
+// returns its trimmed standard output
+func (r *Repo) Run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	if r.WorkTree != "" {
+		cmd.Dir = r.WorkTree
+	} else {
+		cmd.Args = append([]string{"git", "--git-dir", r.GitDir}, args...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// CurrentBranch returns the name of the checked out branch
+func (r *Repo) CurrentBranch() (string, error) {
+	return r.Run("symbolic-ref", "--short", "HEAD")
+}
+
+// HasBranch reports whether a local branch exists
+func (r *Repo) HasBranch(branch string) bool {
+	_, err := r.Run("rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+// Branches returns the local branches whose name starts with prefix
+func (r *Repo) Branches(prefix string) ([]string, error) {
+	out, err := r.Run("for-each-ref", "--format=%(refname:short)", "refs/heads/"+prefix)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// AddRemote adds a new remote and records it in the loaded config
+func (r *Repo) AddRemote(name, url string) error {
+	if _, err := r.Run("remote", "add", "--", name, url); err != nil {
+		return err
+	}
+	return r.reloadConfig()
+}
+
+func (r *Repo) reloadConfig() error {
+	repo, err := openRepo(r.GitDir, r.WorkTree)
+	if err != nil {
+		return err
+	}
+	r.Config = repo.Config
+	return nil
+}
+
+// Commit is a commit as returned by Log
+type Commit struct {
+	Hash    string
+	Subject string
+	Body    string
+}
+
+// Log returns the commits reachable from head but not from base, the
+// oldest commit first
+func (r *Repo) Log(base, head string) ([]Commit, error) {
+	if strings.HasPrefix(base, "-") || strings.HasPrefix(head, "-") {
+		return nil, fmt.Errorf("invalid revision range %s..%s", base, head)
+	}
+	out, err := r.Run("log", "--reverse", "--format=%H%x00%s%x00%b%x1e", base+".."+head, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, entry := range strings.Split(out, "\x1e") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "\x00", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    parts[0],
+			Subject: parts[1],
+			Body:    strings.TrimSpace(parts[2]),
+		})
+	}
+	return commits, nil
+}
+
+// Upstream returns the remote and the remote branch tracked by branch
+func (r *Repo) Upstream(branch string) (string, string, error) {
+	remote, err := r.Run("config", "--get", "branch."+branch+".remote")
+	if err != nil {
+		return "", "", err
+	}
+	merge, err := r.Run("config", "--get", "branch."+branch+".merge")
+	if err != nil {
+		return "", "", err
+	}
+	return remote, strings.TrimPrefix(merge, "refs/heads/"), nil
+}
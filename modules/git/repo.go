@@ -0,0 +1,271 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"code.gitea.io/tea/modules/utils"
+
+	git_config "gopkg.in/src-d/go-git.v4/config"
+	format_config "gopkg.in/src-d/go-git.v4/plumbing/format/config"
+)
+
+// ErrNotRepository is returned when no git repository could be found
+var ErrNotRepository = errors.New("not a git repository (or any of the parent directories)")
+
+// Repo represents a local git repository
+type Repo struct {
+	// GitDir is the git directory of the repository, for worktrees and
+	// submodules this is the directory a .git file points to
+	GitDir string
+	// CommonDir is the directory holding the shared config, it only
+	// differs from GitDir for linked worktrees
+	CommonDir string
+	// WorkTree is the top level directory of the checkout, it is empty
+	// for bare repositories
+	WorkTree string
+	// Config is the parsed repository config
+	Config *git_config.Config
+
+	rewrites []urlRewrite
+}
+
+type urlRewrite struct {
+	base   string
+	prefix string
+}
+
+// CurrentRepo discovers the git repository of the working directory,
+// honoring the GIT_DIR and GIT_WORK_TREE environment variables
+func CurrentRepo() (*Repo, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	if gitDir := os.Getenv("GIT_DIR"); gitDir != "" {
+		if !filepath.IsAbs(gitDir) {
+			gitDir = filepath.Join(cwd, gitDir)
+		}
+		workTree := os.Getenv("GIT_WORK_TREE")
+		if workTree == "" {
+			workTree = cwd
+		}
+		return openRepo(gitDir, workTree)
+	}
+
+	return FindRepo(cwd)
+}
+
+// FindRepo searches the git repository containing dir, walking up the
+// directory tree until a .git directory or file is found
+func FindRepo(dir string) (*Repo, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		fi, err := os.Stat(dotGit)
+		if err == nil {
+			if fi.IsDir() {
+				return openRepo(dotGit, dir)
+			}
+			gitDir, err := readGitFile(dotGit)
+			if err != nil {
+				return nil, err
+			}
+			return openRepo(gitDir, dir)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		if isGitDir(dir) {
+			return openRepo(dir, "")
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrNotRepository
+		}
+		dir = parent
+	}
+}
+
+// readGitFile resolves a .git file as used by worktrees and submodules
+func readGitFile(path string) (string, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	content := strings.TrimSpace(string(bs))
+	if !strings.HasPrefix(content, "gitdir:") {
+		return "", fmt.Errorf("invalid gitfile format: %s", path)
+	}
+
+	gitDir := strings.TrimSpace(strings.TrimPrefix(content, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+// isGitDir reports whether dir looks like a (bare) git directory
+func isGitDir(dir string) bool {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func openRepo(gitDir, workTree string) (*Repo, error) {
+	if _, err := os.Stat(gitDir); err != nil {
+		return nil, ErrNotRepository
+	}
+
+	commonDir := gitDir
+	if bs, err := ioutil.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(bs))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		commonDir = filepath.Clean(commonDir)
+	}
+
+	cfg := git_config.NewConfig()
+	bs, err := ioutil.ReadFile(filepath.Join(commonDir, "config"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := cfg.Unmarshal(bs); err != nil {
+		return nil, fmt.Errorf("parse git config failed: %v", err)
+	}
+
+	if cfg.Core.Worktree != "" {
+		workTree = cfg.Core.Worktree
+		if !filepath.IsAbs(workTree) {
+			workTree = filepath.Join(gitDir, workTree)
+		}
+	}
+
+	repo := &Repo{
+		GitDir:    gitDir,
+		CommonDir: commonDir,
+		WorkTree:  workTree,
+		Config:    cfg,
+	}
+
+	for _, raw := range globalConfigs() {
+		repo.addRewrites(raw)
+	}
+	repo.addRewrites(cfg.Raw)
+
+	return repo, nil
+}
+
+// globalConfigs loads the user level git configs, errors are ignored
+// since a missing global config is perfectly valid
+func globalConfigs() []*format_config.Config {
+	var paths []string
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		paths = append(paths, filepath.Join(xdg, "git", "config"))
+	}
+	if home, err := utils.Home(); err == nil {
+		if os.Getenv("XDG_CONFIG_HOME") == "" {
+			paths = append(paths, filepath.Join(home, ".config", "git", "config"))
+		}
+		paths = append(paths, filepath.Join(home, ".gitconfig"))
+	}
+
+	var configs []*format_config.Config
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			continue
+		}
+		raw := format_config.New()
+		err = format_config.NewDecoder(f).Decode(raw)
+		f.Close()
+		if err == nil {
+			configs = append(configs, raw)
+		}
+	}
+	return configs
+}
+
+func (r *Repo) addRewrites(raw *format_config.Config) {
+	if raw == nil {
+		return
+	}
+	for _, section := range raw.Sections {
+		if !section.IsName("url") {
+			continue
+		}
+		for _, sub := range section.Subsections {
+			for _, prefix := range sub.Options.GetAll("insteadOf") {
+				r.rewrites = append(r.rewrites, urlRewrite{base: sub.Name, prefix: prefix})
+			}
+		}
+	}
+}
+
+// RewriteURL applies the url.<base>.insteadOf rules to rawURL, the
+// longest matching prefix wins like it does in git
+func (r *Repo) RewriteURL(rawURL string) string {
+	var best *urlRewrite
+	for i, rw := range r.rewrites {
+		if !strings.HasPrefix(rawURL, rw.prefix) {
+			continue
+		}
+		if best == nil || len(rw.prefix) > len(best.prefix) {
+			best = &r.rewrites[i]
+		}
+	}
+	if best == nil {
+		return rawURL
+	}
+	return best.base + strings.TrimPrefix(rawURL, best.prefix)
+}
+
+// RemoteNames returns the configured remote names, origin always first
+// and the others in alphabetical order
+func (r *Repo) RemoteNames() []string {
+	names := make([]string, 0, len(r.Config.Remotes))
+	for name := range r.Config.Remotes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == "origin" || names[j] == "origin" {
+			return names[i] == "origin"
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// RemoteURLs returns the rewritten URLs of the named remote
+func (r *Repo) RemoteURLs(name string) ([]string, error) {
+	remote, ok := r.Config.Remotes[name]
+	if !ok || remote == nil {
+		return nil, fmt.Errorf("No remote %s found on this git repository", name)
+	}
+
+	urls := make([]string, 0, len(remote.URLs))
+	for _, u := range remote.URLs {
+		urls = append(urls, r.RewriteURL(strings.TrimSpace(u)))
+	}
+	return urls, nil
+}
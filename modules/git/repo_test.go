@@ -0,0 +1,338 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates the files below dir, parent directories included
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// gitDirFiles are the files making a directory look like a git directory
+func gitDirFiles(prefix, config string) map[string]string {
+	return map[string]string{
+		prefix + "HEAD":             "ref: refs/heads/master\n",
+		prefix + "objects/.keep":    "",
+		prefix + "refs/heads/.keep": "",
+		prefix + "config":           config,
+	}
+}
+
+func mergeFiles(all ...map[string]string) map[string]string {
+	files := make(map[string]string)
+	for _, m := range all {
+		for k, v := range m {
+			files[k] = v
+		}
+	}
+	return files
+}
+
+const originConfig = `[remote "origin"]
+	url = https://gitea.com/owner/repo.git
+`
+
+func TestFindRepo(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		start     string
+		gitDir    string
+		commonDir string
+		workTree  string
+		remotes   []string
+		err       string
+	}{
+		{
+			name:      "git directory",
+			files:     gitDirFiles("repo/.git/", originConfig),
+			start:     "repo",
+			gitDir:    "repo/.git",
+			commonDir: "repo/.git",
+			workTree:  "repo",
+			remotes:   []string{"origin"},
+		},
+		{
+			name: "from a subdirectory",
+			files: mergeFiles(gitDirFiles("repo/.git/", originConfig), map[string]string{
+				"repo/a/b/file": "",
+			}),
+			start:     "repo/a/b",
+			gitDir:    "repo/.git",
+			commonDir: "repo/.git",
+			workTree:  "repo",
+			remotes:   []string{"origin"},
+		},
+		{
+			name: "relative git file of a submodule",
+			files: mergeFiles(gitDirFiles("repo/.git/modules/sub/", originConfig), map[string]string{
+				"repo/sub/.git": "gitdir: ../.git/modules/sub\n",
+			}),
+			start:     "repo/sub",
+			gitDir:    "repo/.git/modules/sub",
+			commonDir: "repo/.git/modules/sub",
+			workTree:  "repo/sub",
+			remotes:   []string{"origin"},
+		},
+		{
+			name: "linked worktree with commondir",
+			files: mergeFiles(gitDirFiles("repo/.git/", originConfig), map[string]string{
+				"repo/.git/worktrees/wt/HEAD":      "ref: refs/heads/feature\n",
+				"repo/.git/worktrees/wt/commondir": "../..\n",
+				"wt/.git":                          "gitdir: ../repo/.git/worktrees/wt",
+			}),
+			start:     "wt",
+			gitDir:    "repo/.git/worktrees/wt",
+			commonDir: "repo/.git",
+			workTree:  "wt",
+			remotes:   []string{"origin"},
+		},
+		{
+			name:      "bare repository",
+			files:     gitDirFiles("repo.git/", originConfig),
+			start:     "repo.git",
+			gitDir:    "repo.git",
+			commonDir: "repo.git",
+			remotes:   []string{"origin"},
+		},
+		{
+			name: "core.worktree",
+			files: gitDirFiles("repo/.git/", `[core]
+	worktree = ../../elsewhere
+`),
+			start:     "repo",
+			gitDir:    "repo/.git",
+			commonDir: "repo/.git",
+			workTree:  "elsewhere",
+			remotes:   []string{},
+		},
+		{
+			name: "invalid git file",
+			files: map[string]string{
+				"repo/.git": "not a gitfile",
+			},
+			start: "repo",
+			err:   "invalid gitfile format",
+		},
+		{
+			name: "no repository",
+			files: map[string]string{
+				"plain/file": "",
+			},
+			start: "plain",
+			err:   ErrNotRepository.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "tea-repo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+			// the temp directory may be a symlink, git paths are compared resolved
+			if root, err = filepath.EvalSymlinks(root); err != nil {
+				t.Fatal(err)
+			}
+			writeFiles(t, root, tt.files)
+
+			repo, err := FindRepo(filepath.Join(root, tt.start))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			abs := func(p string) string {
+				if p == "" {
+					return ""
+				}
+				return filepath.Join(root, filepath.FromSlash(p))
+			}
+			if repo.GitDir != abs(tt.gitDir) {
+				t.Errorf("GitDir = %s, expected %s", repo.GitDir, abs(tt.gitDir))
+			}
+			if repo.CommonDir != abs(tt.commonDir) {
+				t.Errorf("CommonDir = %s, expected %s", repo.CommonDir, abs(tt.commonDir))
+			}
+			if filepath.Clean(repo.WorkTree) != filepath.Clean(abs(tt.workTree)) {
+				t.Errorf("WorkTree = %s, expected %s", repo.WorkTree, abs(tt.workTree))
+			}
+			if names := repo.RemoteNames(); !reflect.DeepEqual(names, tt.remotes) {
+				t.Errorf("RemoteNames() = %v, expected %v", names, tt.remotes)
+			}
+		})
+	}
+}
+
+func TestCurrentRepoGitDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "tea-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, root, gitDirFiles("store/repo.git/", originConfig))
+	writeFiles(t, root, map[string]string{"checkout/file": "", "tree/file": ""})
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+	if err = os.Chdir(filepath.Join(root, "checkout")); err != nil {
+		t.Fatal(err)
+	}
+	defer restoreEnv("GIT_DIR")()
+	defer restoreEnv("GIT_WORK_TREE")()
+
+	tests := []struct {
+		name     string
+		gitDir   string
+		workTree string
+		expected string
+	}{
+		{"relative GIT_DIR", "../store/repo.git", "", "checkout"},
+		{"absolute GIT_DIR", filepath.Join(root, "store/repo.git"), "", "checkout"},
+		{"GIT_WORK_TREE", "../store/repo.git", filepath.Join(root, "tree"), "tree"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("GIT_DIR", tt.gitDir)
+			os.Setenv("GIT_WORK_TREE", tt.workTree)
+
+			repo, err := CurrentRepo()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := filepath.Join(root, "store/repo.git"); filepath.Clean(repo.GitDir) != expected {
+				t.Errorf("GitDir = %s, expected %s", repo.GitDir, expected)
+			}
+			if expected := filepath.Join(root, tt.expected); repo.WorkTree != expected {
+				t.Errorf("WorkTree = %s, expected %s", repo.WorkTree, expected)
+			}
+		})
+	}
+
+	os.Setenv("GIT_DIR", filepath.Join(root, "missing"))
+	if _, err := CurrentRepo(); err != ErrNotRepository {
+		t.Errorf("expected %v for a missing GIT_DIR, got %v", ErrNotRepository, err)
+	}
+}
+
+// restoreEnv returns a function setting the variable back to its current
+// value
+func restoreEnv(key string) func() {
+	value, ok := os.LookupEnv(key)
+	return func() {
+		if ok {
+			os.Setenv(key, value)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestRewriteURL(t *testing.T) {
+	root, err := ioutil.TempDir("", "tea-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	writeFiles(t, root, gitDirFiles("repo/.git/", `[remote "origin"]
+	url = tea-test:owner/repo.git
+	url = tea-test-mirror:owner/repo.git
+[remote "upstream"]
+	url = tea-test-long:org/repo.git
+[url "https://gitea.com/"]
+	insteadOf = tea-test:
+	insteadOf = tea-test-mirror:
+[url "ssh://git@gitea.com:2222/"]
+	insteadOf = tea-test-long:
+[url "https://example.com/"]
+	insteadOf = tea-test-
+`))
+
+	repo, err := FindRepo(filepath.Join(root, "repo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"tea-test:owner/repo.git", "https://gitea.com/owner/repo.git"},
+		{"tea-test-mirror:owner/repo.git", "https://gitea.com/owner/repo.git"},
+		// the longest matching prefix wins over tea-test-
+		{"tea-test-long:org/repo.git", "ssh://git@gitea.com:2222/org/repo.git"},
+		{"tea-test-other/repo.git", "https://example.com/other/repo.git"},
+		{"https://gitea.io/owner/repo.git", "https://gitea.io/owner/repo.git"},
+	}
+	for _, tt := range tests {
+		if actual := repo.RewriteURL(tt.url); actual != tt.expected {
+			t.Errorf("RewriteURL(%q) = %q, expected %q", tt.url, actual, tt.expected)
+		}
+	}
+
+	urls, err := repo.RemoteURLs("origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"https://gitea.com/owner/repo.git", "https://gitea.com/owner/repo.git"}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Errorf("RemoteURLs(origin) = %v, expected %v", urls, expected)
+	}
+	if _, err = repo.RemoteURLs("missing"); err == nil {
+		t.Error("expected an error for a missing remote")
+	}
+}
+
+func TestRemoteNames(t *testing.T) {
+	root, err := ioutil.TempDir("", "tea-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	writeFiles(t, root, gitDirFiles("repo/.git/", `[remote "upstream"]
+	url = https://gitea.com/org/repo.git
+[remote "fork"]
+	url = https://gitea.com/user/repo.git
+[remote "origin"]
+	url = https://gitea.com/owner/repo.git
+`))
+
+	repo, err := FindRepo(filepath.Join(root, "repo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"origin", "fork", "upstream"}
+	if names := repo.RemoteNames(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("RemoteNames() = %v, expected %v", names, expected)
+	}
+}
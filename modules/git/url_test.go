@@ -0,0 +1,36 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import "testing"
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url    string
+		scheme string
+		user   string
+		host   string
+		path   string
+	}{
+		{"https://gitea.com/owner/repo.git", "https", "", "gitea.com", "/owner/repo.git"},
+		{"http://localhost:3000/sub/owner/repo", "http", "", "localhost:3000", "/sub/owner/repo"},
+		{"git@gitea.com:owner/repo.git", "ssh", "git", "gitea.com", "/owner/repo.git"},
+		{"ssh://git@gitea.com:2222/owner/repo.git", "ssh", "git", "gitea.com:2222", "/owner/repo.git"},
+		{"git+ssh://git@gitea.com/owner/repo.git", "ssh", "git", "gitea.com", "/owner/repo.git"},
+		{"ssh://git@gitea.com//owner/repo.git", "ssh", "git", "gitea.com", "/owner/repo.git"},
+	}
+
+	for _, tt := range tests {
+		u, err := ParseURL(tt.url)
+		if err != nil {
+			t.Errorf("ParseURL(%q) failed: %v", tt.url, err)
+			continue
+		}
+		if u.Scheme != tt.scheme || u.User.Username() != tt.user || u.Host != tt.host || u.Path != tt.path {
+			t.Errorf("ParseURL(%q) = %s %s %s %s, expected %s %s %s %s", tt.url,
+				u.Scheme, u.User.Username(), u.Host, u.Path, tt.scheme, tt.user, tt.host, tt.path)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"time"
+)
+
+// Options configures how a Gitea server is reached
+type Options struct {
+	// Insecure disables the verification of the server certificate
+	Insecure bool
+	// CAFile is a PEM file with additional certificate authorities
+	CAFile string
+	// ClientCert and ClientKey are PEM files for TLS client authentication
+	ClientCert string
+	ClientKey  string
+	// Proxy is the URL of the proxy server, empty uses the environment
+	// variables HTTP_PROXY and HTTPS_PROXY, "direct" disables proxies
+	Proxy string
+}
+
+// New returns an http client configured by opts
+func New(opts Options) (*http.Client, error) {
+	tlsConfig, err := tlsConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	switch opts.Proxy {
+	case "":
+	case "direct":
+		proxy = nil
+	default:
+		u, err := url.Parse(opts.Proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %s", opts.Proxy)
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Jar: jar,
+		Transport: &http.Transport{
+			Proxy:                 proxy,
+			TLSClientConfig:       tlsConfig,
+			TLSHandshakeTimeout:   10 * time.Second,
+			IdleConnTimeout:       90 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+	}, nil
+}
+
+func tlsConfig(opts Options) (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: opts.Insecure}
+
+	if opts.CAFile != "" {
+		pem, err := ioutil.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		// trust the system roots as well, the server may be moved
+		// behind a public certificate at any time
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if opts.ClientCert != "" || opts.ClientKey != "" {
+		if opts.ClientCert == "" || opts.ClientKey == "" {
+			return nil, errors.New("client certificate and key have to be given together")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.ClientCert, opts.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading the client certificate failed: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
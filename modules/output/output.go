@@ -0,0 +1,223 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/go-gitea/yaml"
+)
+
+// Supported output formats
+const (
+	FormatTable  = "table"
+	FormatSimple = "simple"
+	FormatCSV    = "csv"
+	FormatTSV    = "tsv"
+	FormatJSON   = "json"
+	FormatYAML   = "yaml"
+)
+
+// Formats lists all supported output formats
+var Formats = []string{FormatTable, FormatSimple, FormatCSV, FormatTSV, FormatJSON, FormatYAML}
+
+// Table represents a list of items to print, the rows are used by the
+// textual formats while the structured formats and templates use the
+// original items
+type Table struct {
+	// Columns are the machine-readable column names
+	Columns []string
+	Rows    [][]string
+	// Items is the slice of objects the rows were built from
+	Items interface{}
+}
+
+// AddRow appends a row of values to the table
+func (t *Table) AddRow(values ...string) {
+	t.Rows = append(t.Rows, values)
+}
+
+// Options configures how a table is printed
+type Options struct {
+	// Format is one of the supported formats, defaults to table
+	Format string
+	// Template is a go template executed for every item, it has
+	// precedence over Format
+	Template string
+}
+
+// UsageError is returned for invalid options, which were given wrongly by
+// the user rather than failed
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+// Validate checks the options for unknown formats and invalid templates
+func (o Options) Validate() error {
+	if o.Template != "" {
+		if _, err := template.New("format").Parse(o.Template); err != nil {
+			return &UsageError{err}
+		}
+		return nil
+	}
+	if o.Format == "" {
+		return nil
+	}
+	for _, f := range Formats {
+		if o.Format == f {
+			return nil
+		}
+	}
+	return &UsageError{fmt.Errorf("unknown output format %s, expected one of %s", o.Format, strings.Join(Formats, ", "))}
+}
+
+// IsStructured reports whether the output is meant for machines rather
+// than humans, so informational messages should be left out
+func (o Options) IsStructured() bool {
+	return o.Template != "" || (o.Format != "" && o.Format != FormatTable)
+}
+
+// Print writes the table to w according to the options
+func (o Options) Print(w io.Writer, t *Table) error {
+	if o.Template != "" {
+		return printTemplate(w, o.Template, t.Items)
+	}
+
+	switch o.Format {
+	case "", FormatTable:
+		return printTable(w, t)
+	case FormatSimple:
+		for _, row := range t.Rows {
+			if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCSV:
+		return printDelimited(w, ',', t)
+	case FormatTSV:
+		return printDelimited(w, '\t', t)
+	case FormatJSON:
+		return printJSON(w, nonNilSlice(t.Items))
+	case FormatYAML:
+		return printYAML(w, nonNilSlice(t.Items))
+	}
+	return fmt.Errorf("unknown output format %s", o.Format)
+}
+
+// PrintItem writes a single object, textual formats fall back to the
+// given table representation of it
+func (o Options) PrintItem(w io.Writer, item interface{}, t *Table) error {
+	if o.Template != "" {
+		return printTemplate(w, o.Template, []interface{}{item})
+	}
+
+	switch o.Format {
+	case FormatJSON:
+		return printJSON(w, item)
+	case FormatYAML:
+		return printYAML(w, item)
+	}
+	return o.Print(w, t)
+}
+
+func printTable(w io.Writer, t *Table) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	headers := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		headers[i] = strings.ToUpper(strings.Replace(c, "_", " ", -1))
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range t.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func printDelimited(w io.Writer, delimiter rune, t *Table) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = delimiter
+	if err := cw.Write(t.Columns); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printYAML marshals v through JSON first, so that the keys match the
+// JSON field names of the SDK structs
+func printYAML(w io.Writer, v interface{}) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	if err = json.Unmarshal(bs, &generic); err != nil {
+		return err
+	}
+	bs, err = yaml.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bs)
+	return err
+}
+
+func printTemplate(w io.Writer, tmpl string, items interface{}) error {
+	t, err := template.New("format").Parse(tmpl)
+	if err != nil {
+		return err
+	}
+
+	return forEach(items, func(item interface{}) error {
+		if err := t.Execute(w, item); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(w)
+		return err
+	})
+}
+
+// forEach calls fn for every element of the slice items
+func forEach(items interface{}, fn func(interface{}) error) error {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Errorf("cannot iterate over %T", items)
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := fn(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nonNilSlice makes sure empty lists are encoded as [] instead of null
+func nonNilSlice(items interface{}) interface{} {
+	v := reflect.ValueOf(items)
+	if !v.IsValid() || (v.Kind() == reflect.Slice && v.IsNil()) {
+		return []interface{}{}
+	}
+	return items
+}
@@ -0,0 +1,150 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+type item struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func testTable(items []*item) *Table {
+	t := &Table{
+		Columns: []string{"name", "open_count"},
+		Items:   items,
+	}
+	for _, i := range items {
+		t.AddRow(i.Name, strconv.Itoa(i.Count))
+	}
+	return t
+}
+
+func TestPrint(t *testing.T) {
+	items := []*item{{"bug", 3}, {"help, wanted", 0}}
+
+	tests := []struct {
+		opt      Options
+		items    []*item
+		expected string
+	}{
+		{Options{}, items, "NAME          OPEN COUNT\nbug           3\nhelp, wanted  0\n"},
+		{Options{Format: FormatTable}, nil, "NAME  OPEN COUNT\n"},
+		{Options{Format: FormatSimple}, items, "bug\t3\nhelp, wanted\t0\n"},
+		{Options{Format: FormatCSV}, items, "name,open_count\nbug,3\n\"help, wanted\",0\n"},
+		{Options{Format: FormatTSV}, items, "name\topen_count\nbug\t3\nhelp, wanted\t0\n"},
+		{Options{Format: FormatJSON}, items, `[
+  {
+    "name": "bug",
+    "count": 3
+  },
+  {
+    "name": "help, wanted",
+    "count": 0
+  }
+]
+`},
+		{Options{Format: FormatJSON}, nil, "[]\n"},
+		{Options{Format: FormatYAML}, items, "- count: 3\n  name: bug\n- count: 0\n  name: help, wanted\n"},
+		{Options{Format: FormatYAML}, nil, "[]\n"},
+		{Options{Format: FormatCSV, Template: "{{.Name}}={{.Count}}"}, items, "bug=3\nhelp, wanted=0\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := tt.opt.Print(&buf, testTable(tt.items)); err != nil {
+			t.Errorf("%+v: Print() failed: %v", tt.opt, err)
+			continue
+		}
+		if buf.String() != tt.expected {
+			t.Errorf("%+v: Print() = %q, expected %q", tt.opt, buf.String(), tt.expected)
+		}
+	}
+}
+
+func TestPrintItem(t *testing.T) {
+	i := &item{"bug", 3}
+
+	tests := []struct {
+		opt      Options
+		expected string
+	}{
+		{Options{}, "NAME  OPEN COUNT\nbug   3\n"},
+		{Options{Format: FormatCSV}, "name,open_count\nbug,3\n"},
+		{Options{Format: FormatJSON}, "{\n  \"name\": \"bug\",\n  \"count\": 3\n}\n"},
+		{Options{Format: FormatYAML}, "count: 3\nname: bug\n"},
+		{Options{Template: "{{.Name}}"}, "bug\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := tt.opt.PrintItem(&buf, i, testTable([]*item{i})); err != nil {
+			t.Errorf("%+v: PrintItem() failed: %v", tt.opt, err)
+			continue
+		}
+		if buf.String() != tt.expected {
+			t.Errorf("%+v: PrintItem() = %q, expected %q", tt.opt, buf.String(), tt.expected)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		opt   Options
+		valid bool
+	}{
+		{Options{}, true},
+		{Options{Format: FormatTable}, true},
+		{Options{Format: FormatSimple}, true},
+		{Options{Format: FormatCSV}, true},
+		{Options{Format: FormatTSV}, true},
+		{Options{Format: FormatJSON}, true},
+		{Options{Format: FormatYAML}, true},
+		{Options{Format: "xml"}, false},
+		{Options{Template: "{{.Name}}"}, true},
+		{Options{Template: "{{.Name"}, false},
+		// a template has precedence over the format
+		{Options{Format: "xml", Template: "{{.Name}}"}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.opt.Validate()
+		if tt.valid {
+			if err != nil {
+				t.Errorf("%+v: Validate() failed: %v", tt.opt, err)
+			}
+			continue
+		}
+		if _, ok := err.(*UsageError); !ok {
+			t.Errorf("%+v: Validate() = %v, expected a usage error", tt.opt, err)
+		}
+	}
+}
+
+func TestIsStructured(t *testing.T) {
+	tests := []struct {
+		opt      Options
+		expected bool
+	}{
+		{Options{}, false},
+		{Options{Format: FormatTable}, false},
+		{Options{Format: FormatSimple}, true},
+		{Options{Format: FormatCSV}, true},
+		{Options{Format: FormatTSV}, true},
+		{Options{Format: FormatJSON}, true},
+		{Options{Format: FormatYAML}, true},
+		{Options{Template: "{{.Name}}"}, true},
+	}
+
+	for _, tt := range tests {
+		if actual := tt.opt.IsStructured(); actual != tt.expected {
+			t.Errorf("%+v: IsStructured() = %v, expected %v", tt.opt, actual, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Editor returns the editor configured by the user, following the
+// same lookup order as git does
+func Editor() string {
+	for _, env := range []string{"TEA_EDITOR", "GIT_EDITOR", "VISUAL", "EDITOR"} {
+		if editor := os.Getenv(env); editor != "" {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// OpenEditor lets the user edit content in the configured editor and
+// returns the result. Lines starting with commentPrefix are stripped,
+// an empty commentPrefix keeps all lines.
+func OpenEditor(content, commentPrefix string) (string, error) {
+	f, err := ioutil.TempFile("", "tea-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.WriteString(content); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err = f.Close(); err != nil {
+		return "", err
+	}
+
+	// the editor setting may contain arguments, so let the shell split it
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/c", Editor()+" "+f.Name())
+	} else {
+		cmd = exec.Command("sh", "-c", Editor()+` "$0"`, f.Name())
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err = cmd.Run(); err != nil {
+		return "", err
+	}
+
+	bs, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+
+	result := string(bs)
+	if commentPrefix != "" {
+		lines := strings.Split(result, "\n")
+		kept := lines[:0]
+		for _, line := range lines {
+			if !strings.HasPrefix(line, commentPrefix) {
+				kept = append(kept, line)
+			}
+		}
+		result = strings.Join(kept, "\n")
+	}
+	return strings.TrimSpace(result), nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// ReadLine reads a single line from stdin without the line ending
+func ReadLine() (string, error) {
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Prompt prints the question to stderr and reads the answer from stdin
+func Prompt(question string) (string, error) {
+	fmt.Fprint(os.Stderr, question)
+	answer, err := ReadLine()
+	return strings.TrimSpace(answer), err
+}
+
+// PromptPassword works like Prompt but does not echo the answer when
+// stdin is a terminal, terminals which can not hide it are refused
+func PromptPassword(question string) (string, error) {
+	fmt.Fprint(os.Stderr, question)
+	if !IsTerminal(os.Stdin) {
+		return ReadLine()
+	}
+
+	defer fmt.Fprintln(os.Stderr)
+	answer, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", fmt.Errorf("reading the secret without echo failed, pass it as flag or on stdin instead: %v", err)
+	}
+	return strings.TrimSpace(string(answer)), nil
+}
+
+// PromptDefault works like Prompt but returns def for an empty answer
+func PromptDefault(question, def string) (string, error) {
+	if def != "" {
+		question = fmt.Sprintf("%s [%s]", question, def)
+	}
+	answer, err := Prompt(question + ": ")
+	if err != nil || answer != "" {
+		return answer, err
+	}
+	return def, nil
+}
+
+// PromptRequired asks the question until the answer is not empty
+func PromptRequired(question, def string) (string, error) {
+	for {
+		answer, err := PromptDefault(question, def)
+		if err != nil || answer != "" {
+			return answer, err
+		}
+		fmt.Fprintln(os.Stderr, "A value is required.")
+	}
+}
+
+// Select prints the numbered options and asks for one of them, or for a
+// comma-separated list of them if multiple is set. Options can be given
+// by number or by name, an empty answer selects none.
+func Select(question string, options []string, multiple bool) ([]string, error) {
+	for i, o := range options {
+		fmt.Fprintf(os.Stderr, "%3d) %s\n", i+1, o)
+	}
+	hint := "number or name"
+	if multiple {
+		hint = "comma-separated numbers or names"
+	}
+
+	for {
+		answer, err := Prompt(fmt.Sprintf("%s (%s, empty for none): ", question, hint))
+		if err != nil || answer == "" {
+			return nil, err
+		}
+
+		selected, err := parseSelection(answer, options)
+		if err == nil && len(selected) > 1 && !multiple {
+			err = fmt.Errorf("only one %s can be selected", hint)
+		}
+		if err == nil {
+			return selected, nil
+		}
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// parseSelection returns the options named by number or name in answer,
+// each of them once in the order of the answer
+func parseSelection(answer string, options []string) ([]string, error) {
+	var selected []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(answer, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		option := ""
+		if n, err := strconv.Atoi(part); err == nil {
+			if n < 1 || n > len(options) {
+				return nil, fmt.Errorf("%d is not one of the options", n)
+			}
+			option = options[n-1]
+		} else {
+			for _, o := range options {
+				if strings.EqualFold(o, part) {
+					option = o
+					break
+				}
+			}
+			if option == "" {
+				return nil, fmt.Errorf("%s is not one of the options", part)
+			}
+		}
+
+		if !seen[option] {
+			seen[option] = true
+			selected = append(selected, option)
+		}
+	}
+	return selected, nil
+}
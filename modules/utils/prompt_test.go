@@ -0,0 +1,48 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSelection(t *testing.T) {
+	options := []string{"bug", "Feature", "help wanted", "2019"}
+
+	tests := []struct {
+		answer   string
+		expected []string
+		err      bool
+	}{
+		{"1", []string{"bug"}, false},
+		{"2, 1", []string{"Feature", "bug"}, false},
+		{"feature", []string{"Feature"}, false},
+		{"Help Wanted,1", []string{"help wanted", "bug"}, false},
+		// numbers always select by position
+		{"4", []string{"2019"}, false},
+		{"1,bug,1", []string{"bug"}, false},
+		{" , ,", nil, false},
+		{"", nil, false},
+		{"0", nil, true},
+		{"5", nil, true},
+		{"-1", nil, true},
+		{"1,unknown", nil, true},
+		{"help", nil, true},
+	}
+
+	for _, tt := range tests {
+		selected, err := parseSelection(tt.answer, options)
+		if tt.err {
+			if err == nil {
+				t.Errorf("parseSelection(%q) = %q, expected an error", tt.answer, selected)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(selected, tt.expected) {
+			t.Errorf("parseSelection(%q) = %q, %v, expected %q", tt.answer, selected, err, tt.expected)
+		}
+	}
+}